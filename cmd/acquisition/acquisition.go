@@ -12,7 +12,7 @@ import (
 	"github.com/nlnwa/hermetic/cmd/internal/flags"
 	"github.com/nlnwa/hermetic/internal/dps"
 	"github.com/nlnwa/hermetic/internal/path"
-	"github.com/segmentio/kafka-go"
+	"github.com/nlnwa/hermetic/internal/state"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -28,6 +28,12 @@ const (
 ├── <other-small-and-few-files>
 └── /<other-files-and-directories>
 `
+
+	manifestFlagName     string = "manifest"
+	manifestFlagNameHelp string = "include a manifest with the size, modification time, md5 and sha256 of every file in the message"
+
+	stateFileFlagName     string = "state-file"
+	stateFileFlagNameHelp string = "optional path to file used to record the submission"
 )
 
 func addFlags(cmd *cobra.Command) {
@@ -35,29 +41,52 @@ func addFlags(cmd *cobra.Command) {
 	if err := cmd.MarkFlagRequired(dirFlagName); err != nil {
 		panic(err)
 	}
+	cmd.Flags().Bool(manifestFlagName, false, manifestFlagNameHelp)
+	cmd.Flags().String(stateFileFlagName, "", stateFileFlagNameHelp)
+	flags.AddKafkaKeyStrategyFlag(cmd)
 }
 
-func toOptions() AcquisitionOptions {
-	return AcquisitionOptions{
-		KafkaEndpoints: flags.GetKafkaEndpoints(),
-		KafkaTopic:     flags.GetKafkaTopic(),
-		Dir:            viper.GetString(dirFlagName),
+func toOptions() (AcquisitionOptions, error) {
+	keyStrategy, err := flags.GetKafkaKeyStrategy()
+	if err != nil {
+		return AcquisitionOptions{}, err
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return AcquisitionOptions{}, err
 	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return AcquisitionOptions{}, err
+	}
+
+	return AcquisitionOptions{
+		Transport:   transport,
+		KafkaTopic:  flags.GetKafkaTopic(),
+		Dir:         viper.GetString(dirFlagName),
+		Manifest:    viper.GetBool(manifestFlagName),
+		StateFile:   viper.GetString(stateFileFlagName),
+		KeyStrategy: keyStrategy,
+		Naming:      naming,
+	}, nil
 }
 
 type AcquisitionOptions struct {
-	KafkaEndpoints []string
-	KafkaTopic     string
-	Dir            string
+	Transport   dps.Transport
+	KafkaTopic  string
+	Dir         string
+	Manifest    bool
+	StateFile   string
+	KeyStrategy dps.KeyStrategy
+	Naming      dps.NamingScheme
 }
 
 func (o AcquisitionOptions) Run() error {
-	writer := &kafka.Writer{
-		Addr:     kafka.TCP(o.KafkaEndpoints...),
-		Topic:    o.KafkaTopic,
-		Balancer: &kafka.LeastBytes{},
+	producer, err := o.Transport.Producer(o.KafkaTopic, o.KeyStrategy)
+	if err != nil {
+		return err
 	}
-	defer writer.Close()
+	defer producer.Close()
 
 	isDir, err := path.IsDirectory(o.Dir)
 	if err != nil {
@@ -78,22 +107,44 @@ func (o AcquisitionOptions) Run() error {
 
 	identifier := dataModel.ArchiveUnit.Name + "-" + dataModel.ArchiveUnit.Deposit.Date
 
-	message := dps.CreateMessage(o.Dir, identifier, contentType)
+	message := o.Naming.CreateMessage(o.Dir, identifier, contentType)
 
-	expectedURN := "URN:NBN:no-nb_nettarkiv_" + dataModel.ArchiveUnit.Name + "-" + dataModel.ArchiveUnit.Deposit.Date
+	expectedURN := o.Naming.Urn(identifier)
 
 	if message.Urn != expectedURN {
 		return fmt.Errorf("failed to create URN, expected %s, got %s", expectedURN, message.Urn)
 	}
 
+	if o.Manifest {
+		message.Files, err = dps.CreateManifest(o.Dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	var store *state.Store
+	if o.StateFile != "" {
+		store, err = state.Open(o.StateFile)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	err = dps.Send(ctx, writer, message)
+	err = dps.Send(ctx, producer, message, o.KeyStrategy)
 	if err != nil {
 		return fmt.Errorf("failed to send message to kafka topic '%s': %w", o.KafkaTopic, err)
 	}
 
+	if store != nil {
+		if err := store.Put(state.NewRecord(message)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -103,7 +154,14 @@ func NewCommand() *cobra.Command {
 		Short: "Uploads data to digital storage",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return toOptions().Run()
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			opts, err := toOptions()
+			if err != nil {
+				return err
+			}
+			return opts.Run()
 		},
 	}
 
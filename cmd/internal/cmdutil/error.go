@@ -11,12 +11,23 @@ import (
 
 // HandleError sends error message to Teams and returns the error
 func HandleError(err error) error {
+	if err != nil {
+		NotifyError(err)
+	}
+	return err
+}
+
+// NotifyError sends error message to Teams without interrupting the caller
+func NotifyError(err error) {
+	Notify(teams.Error(err))
+}
+
+// Notify sends message to Teams without interrupting the caller
+func Notify(message teams.Message) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := teams.SendMessage(ctx, teams.Error(err), flags.GetTeamsWebhookNotificationUrl()); err != nil {
+	if err := teams.SendMessage(ctx, message, flags.GetTeamsWebhookNotificationUrl()); err != nil {
 		slog.Error(err.Error())
 	}
-
-	return err
 }
@@ -0,0 +1,13 @@
+package cmdutil
+
+import (
+	"log/slog"
+	"time"
+)
+
+// LogRetry returns a callback for retry.Do that logs failed attempts with msg and args
+func LogRetry(msg string, args ...any) func(attempt int, err error, delay time.Duration) {
+	return func(attempt int, err error, delay time.Duration) {
+		slog.Warn(msg, append(args, "attempt", attempt, "error", err, "retryIn", delay)...)
+	}
+}
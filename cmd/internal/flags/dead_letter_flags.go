@@ -0,0 +1,64 @@
+package flags
+
+import (
+	"fmt"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	deadLetterFlagName      string = "dead-letter"
+	deadLetterHelp          string = "what to do with received messages that can not be processed: 'fail', 'skip' (log and skip), 'topic' (forward to --dead-letter-topic) or 'file' (append to --dead-letter-file)"
+	deadLetterTopicFlagName string = "dead-letter-topic"
+	deadLetterTopicHelp     string = "name of topic that messages that can not be processed are forwarded to"
+	deadLetterFileFlagName  string = "dead-letter-file"
+	deadLetterFileHelp      string = "path to file that messages that can not be processed are appended to as JSON lines"
+
+	DeadLetterFail  string = "fail"
+	DeadLetterSkip  string = "skip"
+	DeadLetterTopic string = "topic"
+	DeadLetterFile  string = "file"
+)
+
+func AddDeadLetterFlags(cmd *cobra.Command) {
+	// Fail by default, so responses are never lost to a change of the response schema
+	cmd.Flags().String(deadLetterFlagName, DeadLetterFail, deadLetterHelp)
+	cmd.Flags().String(deadLetterTopicFlagName, "", deadLetterTopicHelp)
+	cmd.Flags().String(deadLetterFileFlagName, "", deadLetterFileHelp)
+}
+
+// GetDeadLetters returns the handler of messages that can not be processed,
+// or nil if they should fail the command. Forwarded messages are written
+// with transport.
+func GetDeadLetters(transport dps.Transport) (*dps.DeadLetters, error) {
+	switch policy := viper.GetString(deadLetterFlagName); policy {
+	case DeadLetterFail:
+		return nil, nil
+	case DeadLetterSkip:
+		return &dps.DeadLetters{}, nil
+	case DeadLetterTopic:
+		topic := viper.GetString(deadLetterTopicFlagName)
+		if topic == "" {
+			return nil, fmt.Errorf("--%s is required with --%s=%s", deadLetterTopicFlagName, deadLetterFlagName, DeadLetterTopic)
+		}
+		producer, err := transport.Producer(topic, dps.KeyRandom)
+		if err != nil {
+			return nil, err
+		}
+		return &dps.DeadLetters{Sink: dps.DeadLetterTopic{Producer: producer}}, nil
+	case DeadLetterFile:
+		path := viper.GetString(deadLetterFileFlagName)
+		if path == "" {
+			return nil, fmt.Errorf("--%s is required with --%s=%s", deadLetterFileFlagName, deadLetterFlagName, DeadLetterFile)
+		}
+		sink, err := dps.OpenDeadLetterFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &dps.DeadLetters{Sink: sink}, nil
+	default:
+		return nil, fmt.Errorf("unknown dead-letter policy '%s', expected one of '%s', '%s', '%s' or '%s'", policy, DeadLetterFail, DeadLetterSkip, DeadLetterTopic, DeadLetterFile)
+	}
+}
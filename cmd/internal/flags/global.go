@@ -15,26 +15,21 @@ const (
 
 func AddGlobalFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String(kafkaTopicFlagName, "", "name of kafka topic")
-	if err := cmd.MarkPersistentFlagRequired(kafkaTopicFlagName); err != nil {
-		panic(err)
-	}
-
 	cmd.PersistentFlags().StringSlice(kafkaEndpointsFlagName, []string{}, "list of kafka endpoints")
-	if err := cmd.MarkPersistentFlagRequired(kafkaEndpointsFlagName); err != nil {
-		panic(err)
-	}
 
 	cmd.PersistentFlags().String(teamsWebhookNotificationUrlFlagName, "", "url to teams webhook for notifications")
+
+	addNamingFlags(cmd)
+	addTransportFlags(cmd)
+	addKafkaAuthFlags(cmd)
 }
 
-func ValidateGlobalFlags() (err error) {
+// ValidateGlobalFlags checks that the topic and transport flags are set, for commands that send or receive messages
+func ValidateGlobalFlags() error {
 	if GetKafkaTopic() == "" {
-		err = errors.New("kafka topic is required")
-	}
-	if len(GetKafkaEndpoints()) == 0 {
-		err = errors.New("kafka endpoints are required")
+		return errors.New("kafka topic is required")
 	}
-	return
+	return validateTransportFlags()
 }
 
 func GetKafkaTopic() string {
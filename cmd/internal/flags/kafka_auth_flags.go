@@ -0,0 +1,84 @@
+package flags
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	kafkaTLSFlagName                   string = "kafka-tls"
+	kafkaTLSHelp                       string = "connect to kafka with TLS (implied by the TLS file flags)"
+	kafkaTLSCAFileFlagName             string = "kafka-tls-ca-file"
+	kafkaTLSCAFileHelp                 string = "path to PEM bundle of certificate authorities used to verify the kafka brokers"
+	kafkaTLSCertFileFlagName           string = "kafka-tls-cert-file"
+	kafkaTLSCertFileHelp               string = "path to PEM client certificate"
+	kafkaTLSKeyFileFlagName            string = "kafka-tls-key-file"
+	kafkaTLSKeyFileHelp                string = "path to PEM key of the client certificate"
+	kafkaTLSInsecureSkipVerifyFlagName string = "kafka-tls-insecure-skip-verify"
+	kafkaTLSInsecureSkipVerifyHelp     string = "do not verify the certificates of the kafka brokers"
+	kafkaSASLMechanismFlagName         string = "kafka-sasl-mechanism"
+	kafkaSASLMechanismHelp             string = "SASL mechanism: 'plain', 'scram-sha-256' or 'scram-sha-512' (default no SASL)"
+	kafkaSASLUsernameFlagName          string = "kafka-sasl-username"
+	kafkaSASLUsernameHelp              string = "SASL username"
+	kafkaSASLUsernameFileFlagName      string = "kafka-sasl-username-file"
+	kafkaSASLUsernameFileHelp          string = "path to file containing the SASL username"
+	kafkaSASLPasswordFlagName          string = "kafka-sasl-password"
+	kafkaSASLPasswordHelp              string = "SASL password, preferably set with HERMETIC_KAFKA_SASL_PASSWORD or --kafka-sasl-password-file"
+	kafkaSASLPasswordFileFlagName      string = "kafka-sasl-password-file"
+	kafkaSASLPasswordFileHelp          string = "path to file containing the SASL password"
+)
+
+func addKafkaAuthFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(kafkaTLSFlagName, false, kafkaTLSHelp)
+	cmd.PersistentFlags().String(kafkaTLSCAFileFlagName, "", kafkaTLSCAFileHelp)
+	cmd.PersistentFlags().String(kafkaTLSCertFileFlagName, "", kafkaTLSCertFileHelp)
+	cmd.PersistentFlags().String(kafkaTLSKeyFileFlagName, "", kafkaTLSKeyFileHelp)
+	cmd.PersistentFlags().Bool(kafkaTLSInsecureSkipVerifyFlagName, false, kafkaTLSInsecureSkipVerifyHelp)
+	cmd.PersistentFlags().String(kafkaSASLMechanismFlagName, "", kafkaSASLMechanismHelp)
+	cmd.PersistentFlags().String(kafkaSASLUsernameFlagName, "", kafkaSASLUsernameHelp)
+	cmd.PersistentFlags().String(kafkaSASLUsernameFileFlagName, "", kafkaSASLUsernameFileHelp)
+	cmd.PersistentFlags().String(kafkaSASLPasswordFlagName, "", kafkaSASLPasswordHelp)
+	cmd.PersistentFlags().String(kafkaSASLPasswordFileFlagName, "", kafkaSASLPasswordFileHelp)
+}
+
+// GetKafkaAuth returns the kafka TLS and SASL configuration. Credentials given
+// in files take precedence over credentials given directly.
+func GetKafkaAuth() (dps.KafkaAuth, error) {
+	username, err := secret(kafkaSASLUsernameFlagName, kafkaSASLUsernameFileFlagName)
+	if err != nil {
+		return dps.KafkaAuth{}, err
+	}
+	password, err := secret(kafkaSASLPasswordFlagName, kafkaSASLPasswordFileFlagName)
+	if err != nil {
+		return dps.KafkaAuth{}, err
+	}
+
+	return dps.KafkaAuth{
+		TLS:                viper.GetBool(kafkaTLSFlagName),
+		CAFile:             viper.GetString(kafkaTLSCAFileFlagName),
+		CertFile:           viper.GetString(kafkaTLSCertFileFlagName),
+		KeyFile:            viper.GetString(kafkaTLSKeyFileFlagName),
+		InsecureSkipVerify: viper.GetBool(kafkaTLSInsecureSkipVerifyFlagName),
+		SASLMechanism:      strings.ToLower(viper.GetString(kafkaSASLMechanismFlagName)),
+		Username:           username,
+		Password:           password,
+	}, nil
+}
+
+// secret returns the content of the file given by fileFlagName if set, else the value of flagName.
+func secret(flagName string, fileFlagName string) (string, error) {
+	path := viper.GetString(fileFlagName)
+	if path == "" {
+		return viper.GetString(flagName), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read --%s: %w", fileFlagName, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
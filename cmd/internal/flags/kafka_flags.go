@@ -1,6 +1,8 @@
 package flags
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,3 +19,13 @@ func AddKafkaFlags(cmd *cobra.Command) {
 func GetKafkaConsumerGroupID() string {
 	return viper.GetString(kafkaConsumerGroupIDFlagName)
 }
+
+// GetRequiredKafkaConsumerGroupID returns the consumer group ID, which is
+// required by commands that commit messages once they have been processed.
+func GetRequiredKafkaConsumerGroupID() (string, error) {
+	groupID := GetKafkaConsumerGroupID()
+	if groupID == "" {
+		return "", fmt.Errorf("--%s is required, as processed messages can only be committed by a consumer group", kafkaConsumerGroupIDFlagName)
+	}
+	return groupID, nil
+}
@@ -0,0 +1,20 @@
+package flags
+
+import (
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	kafkaKeyStrategyFlagName string = "kafka-key-strategy"
+	kafkaKeyStrategyHelp     string = "how to derive the kafka key of sent messages: 'random', 'urn' or 'path-hash'"
+)
+
+func AddKafkaKeyStrategyFlag(cmd *cobra.Command) {
+	cmd.Flags().String(kafkaKeyStrategyFlagName, string(dps.KeyRandom), kafkaKeyStrategyHelp)
+}
+
+func GetKafkaKeyStrategy() (dps.KeyStrategy, error) {
+	return dps.ParseKeyStrategy(viper.GetString(kafkaKeyStrategyFlagName))
+}
@@ -0,0 +1,19 @@
+package flags
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	lifecycleFileFlagName string = "lifecycle-file"
+	lifecycleFileHelp     string = "path to file with the lifecycle of every submission, maintained by 'hermetic track'"
+)
+
+func AddLifecycleFileFlag(cmd *cobra.Command) {
+	cmd.Flags().String(lifecycleFileFlagName, "hermetic-lifecycle.db", lifecycleFileHelp)
+}
+
+func GetLifecycleFile() string {
+	return viper.GetString(lifecycleFileFlagName)
+}
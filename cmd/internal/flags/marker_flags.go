@@ -0,0 +1,19 @@
+package flags
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	markerFileFlagName string = "marker-file"
+	markerFileHelp     string = `name of a file that marks a directory as complete, e.g. '.complete'; send waits for it, and it needs no digest in the checksum file`
+)
+
+func AddMarkerFileFlag(cmd *cobra.Command) {
+	cmd.Flags().String(markerFileFlagName, "", markerFileHelp)
+}
+
+func GetMarkerFile() string {
+	return viper.GetString(markerFileFlagName)
+}
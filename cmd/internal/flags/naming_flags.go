@@ -0,0 +1,43 @@
+package flags
+
+import (
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	contentCategoryFlagName    string = "content-category"
+	contentCategoryHelp        string = "content category of submitted packages, only messages with this content category are processed"
+	urnPrefixFlagName          string = "urn-prefix"
+	urnPrefixHelp              string = "prefix of the URN of submitted packages"
+	urnNamespaceFlagName       string = "urn-namespace"
+	urnNamespaceHelp           string = "namespace of the URN and identifier of submitted packages"
+	identifierTemplateFlagName string = "identifier-template"
+	identifierTemplateHelp     string = "template of the URN and identifier of submitted packages, with the placeholders {namespace}, {category} and {name}"
+	identifierSuffixFlagName   string = "identifier-suffix"
+	identifierSuffixHelp       string = "suffix that makes identifiers unique: 'uuid', 'timestamp' or 'none' (resubmissions use 'timestamp' instead of 'none')"
+)
+
+func addNamingFlags(cmd *cobra.Command) {
+	defaults := dps.DefaultNamingScheme
+	cmd.PersistentFlags().String(contentCategoryFlagName, defaults.ContentCategory, contentCategoryHelp)
+	cmd.PersistentFlags().String(urnPrefixFlagName, defaults.UrnPrefix, urnPrefixHelp)
+	cmd.PersistentFlags().String(urnNamespaceFlagName, defaults.Namespace, urnNamespaceHelp)
+	cmd.PersistentFlags().String(identifierTemplateFlagName, defaults.IdentifierTemplate, identifierTemplateHelp)
+	cmd.PersistentFlags().String(identifierSuffixFlagName, defaults.Suffix, identifierSuffixHelp)
+}
+
+func GetNamingScheme() (dps.NamingScheme, error) {
+	scheme := dps.NamingScheme{
+		ContentCategory:    viper.GetString(contentCategoryFlagName),
+		UrnPrefix:          viper.GetString(urnPrefixFlagName),
+		Namespace:          viper.GetString(urnNamespaceFlagName),
+		IdentifierTemplate: viper.GetString(identifierTemplateFlagName),
+		Suffix:             viper.GetString(identifierSuffixFlagName),
+	}
+	if err := scheme.Validate(); err != nil {
+		return dps.NamingScheme{}, err
+	}
+	return scheme, nil
+}
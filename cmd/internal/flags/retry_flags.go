@@ -0,0 +1,36 @@
+package flags
+
+import (
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/retry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	retryAttemptsFlagName   string = "retry-attempts"
+	retryAttemptsHelp       string = "maximum number of attempts to deliver a received message downstream before giving up, without committing it"
+	retryBackoffFlagName    string = "retry-backoff"
+	retryBackoffHelp        string = "delay after the first failed attempt, doubled after every further failure"
+	retryMaxBackoffFlagName string = "retry-max-backoff"
+	retryMaxBackoffHelp     string = "maximum delay between attempts"
+)
+
+func AddRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().Int(retryAttemptsFlagName, 5, retryAttemptsHelp)
+	cmd.Flags().Duration(retryBackoffFlagName, time.Second, retryBackoffHelp)
+	cmd.Flags().Duration(retryMaxBackoffFlagName, time.Minute, retryMaxBackoffHelp)
+}
+
+func GetRetryPolicy() (retry.Policy, error) {
+	policy := retry.Policy{
+		Attempts:   viper.GetInt(retryAttemptsFlagName),
+		Backoff:    viper.GetDuration(retryBackoffFlagName),
+		MaxBackoff: viper.GetDuration(retryMaxBackoffFlagName),
+	}
+	if err := policy.Validate(); err != nil {
+		return retry.Policy{}, err
+	}
+	return policy, nil
+}
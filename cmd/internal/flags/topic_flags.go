@@ -0,0 +1,26 @@
+package flags
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	confirmTopicFlagName string = "confirm-topic"
+	confirmTopicHelp     string = "name of kafka topic with confirm messages from DPS"
+	rejectTopicFlagName  string = "reject-topic"
+	rejectTopicHelp      string = "name of kafka topic with reject messages from DPS"
+)
+
+func AddResponseTopicFlags(cmd *cobra.Command) {
+	cmd.Flags().String(confirmTopicFlagName, "", confirmTopicHelp)
+	cmd.Flags().String(rejectTopicFlagName, "", rejectTopicHelp)
+}
+
+func GetConfirmTopic() string {
+	return viper.GetString(confirmTopicFlagName)
+}
+
+func GetRejectTopic() string {
+	return viper.GetString(rejectTopicFlagName)
+}
@@ -0,0 +1,60 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	transportFlagName string = "transport"
+	transportHelp     string = "how messages are carried: 'kafka', or 'spool' for one JSON file per message in --spool-dir"
+	spoolDirFlagName  string = "spool-dir"
+	spoolDirHelp      string = "directory holding one subdirectory of message files per topic, used with --transport=spool"
+
+	TransportKafka string = "kafka"
+	TransportSpool string = "spool"
+)
+
+func addTransportFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(transportFlagName, TransportKafka, transportHelp)
+	cmd.PersistentFlags().String(spoolDirFlagName, "", spoolDirHelp)
+}
+
+func GetTransportName() string {
+	return viper.GetString(transportFlagName)
+}
+
+func validateTransportFlags() error {
+	switch GetTransportName() {
+	case TransportKafka:
+		if len(GetKafkaEndpoints()) == 0 {
+			return errors.New("kafka endpoints are required")
+		}
+	case TransportSpool:
+		if viper.GetString(spoolDirFlagName) == "" {
+			return fmt.Errorf("--%s is required with --%s=%s", spoolDirFlagName, transportFlagName, TransportSpool)
+		}
+	default:
+		return fmt.Errorf("unknown transport '%s', expected '%s' or '%s'", GetTransportName(), TransportKafka, TransportSpool)
+	}
+	return nil
+}
+
+// GetTransport returns the transport selected by the transport flags.
+func GetTransport() (dps.Transport, error) {
+	if err := validateTransportFlags(); err != nil {
+		return nil, err
+	}
+	if GetTransportName() == TransportSpool {
+		return dps.NewSpoolTransport(viper.GetString(spoolDirFlagName)), nil
+	}
+	auth, err := GetKafkaAuth()
+	if err != nil {
+		return nil, err
+	}
+	return dps.NewKafkaTransport(GetKafkaEndpoints(), auth)
+}
@@ -0,0 +1,359 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/nlnwa/hermetic/cmd/internal/flags"
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/lifecycle"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	sendDirFlagName           string = "send-dir"
+	sendDirHelpMessage        string = "root directory of send, whose subdirectories are WARC directories"
+	acquisitionDirFlagName    string = "acquisition-dir"
+	acquisitionDirHelpMessage string = "comma separated list of directories whose subdirectories with an acquisition.yaml are acquisition roots"
+	minAgeFlagName            string = "min-age"
+	minAgeHelpMessage         string = "only report submissions without a response that are older than this"
+	outputFlagName            string = "output"
+	outputHelpMessage         string = "output format: 'csv' or 'json'"
+
+	outputCsv  string = "csv"
+	outputJson string = "json"
+
+	acquisitionFileName string = "acquisition.yaml"
+)
+
+// Kinds of findings.
+const (
+	// NotSubmitted is a directory on disk that has never been submitted.
+	NotSubmitted = "not-submitted"
+	// NoResponse is a submission without a response from DPS.
+	NoResponse = "no-response"
+	// ConfirmedOnDisk is a confirmed submission whose directory is still on disk.
+	ConfirmedOnDisk = "confirmed-on-disk"
+	// RejectedNotResubmitted is a rejected submission that has not been resubmitted.
+	RejectedNotResubmitted = "rejected-not-resubmitted"
+	// PathMissing is a submission that is not confirmed, whose directory no longer exists.
+	PathMissing = "path-missing"
+)
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().String(sendDirFlagName, "", sendDirHelpMessage)
+	cmd.Flags().StringSlice(acquisitionDirFlagName, nil, acquisitionDirHelpMessage)
+	cmd.Flags().Duration(minAgeFlagName, 0, minAgeHelpMessage)
+	cmd.Flags().String(outputFlagName, outputCsv, outputHelpMessage)
+	flags.AddResponseTopicFlags(cmd)
+}
+
+type ReconcileOptions struct {
+	Transport       dps.Transport
+	KafkaTopic      string
+	ConfirmTopic    string
+	RejectTopic     string
+	SendDir         string
+	AcquisitionDirs []string
+	MinAge          time.Duration
+	Format          string
+	Naming          dps.NamingScheme
+	Output          io.Writer
+}
+
+func toOptions(output io.Writer) (ReconcileOptions, error) {
+	if flags.GetConfirmTopic() == "" || flags.GetRejectTopic() == "" {
+		return ReconcileOptions{}, errors.New("confirm and reject topics are required")
+	}
+	format := viper.GetString(outputFlagName)
+	if format != outputCsv && format != outputJson {
+		return ReconcileOptions{}, fmt.Errorf("unknown output format '%s', expected '%s' or '%s'", format, outputCsv, outputJson)
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return ReconcileOptions{}, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return ReconcileOptions{}, err
+	}
+
+	return ReconcileOptions{
+		Transport:       transport,
+		KafkaTopic:      flags.GetKafkaTopic(),
+		ConfirmTopic:    flags.GetConfirmTopic(),
+		RejectTopic:     flags.GetRejectTopic(),
+		SendDir:         viper.GetString(sendDirFlagName),
+		AcquisitionDirs: viper.GetStringSlice(acquisitionDirFlagName),
+		MinAge:          viper.GetDuration(minAgeFlagName),
+		Format:          format,
+		Naming:          naming,
+		Output:          output,
+	}, nil
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Reports differences between the staging filesystem and the topics",
+		Long: `Reports differences between the staging filesystem and the topics.
+
+The transfer topic (--kafka-topic) and the confirm and reject topics are
+replayed, and the latest submission of every path is compared with the
+directories on disk. Each submission is reported at most once, as the first
+of these that applies:
+
+  not-submitted             directory on disk that has never been submitted
+  confirmed-on-disk         confirmed submission still occupying disk
+  path-missing              submission that is not confirmed, whose directory no longer exists
+  no-response               submission without a response from DPS
+  rejected-not-resubmitted  rejected submission that has not been resubmitted
+
+When directories are given, only submissions within them are reported.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			o, err := toOptions(cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			// Keep stdout for the report
+			slog.SetDefault(slog.New(slog.NewJSONHandler(cmd.ErrOrStderr(), nil)))
+			return o.Run()
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+// Finding is a difference between the filesystem and the topics.
+type Finding struct {
+	Kind       string          `json:"kind"`
+	Path       string          `json:"path"`
+	Identifier string          `json:"identifier,omitempty"`
+	Urn        string          `json:"urn,omitempty"`
+	State      lifecycle.State `json:"state,omitempty"`
+	Date       string          `json:"date,omitempty"`
+}
+
+func (o ReconcileOptions) Run() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	findings, err := o.reconcile(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.Kind]++
+	}
+	slog.Info("Reconciled filesystem with topics", "findings", len(findings), NotSubmitted, counts[NotSubmitted], NoResponse, counts[NoResponse], ConfirmedOnDisk, counts[ConfirmedOnDisk], RejectedNotResubmitted, counts[RejectedNotResubmitted], PathMissing, counts[PathMissing])
+
+	if o.Format == outputJson {
+		encoder := json.NewEncoder(o.Output)
+		encoder.SetIndent("", "  ")
+		if findings == nil {
+			findings = []Finding{}
+		}
+		return encoder.Encode(findings)
+	}
+	return writeCsv(o.Output, findings)
+}
+
+func (o ReconcileOptions) roots() []string {
+	var roots []string
+	if o.SendDir != "" {
+		roots = append(roots, filepath.Clean(o.SendDir))
+	}
+	for _, dir := range o.AcquisitionDirs {
+		roots = append(roots, filepath.Clean(dir))
+	}
+	return roots
+}
+
+// inRoots reports whether path is within one of the roots, or whether there are no roots.
+func inRoots(path string, roots []string) bool {
+	if len(roots) == 0 {
+		return true
+	}
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != "." && filepath.IsLocal(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o ReconcileOptions) reconcile(ctx context.Context, now time.Time) ([]Finding, error) {
+	dirs, err := o.packageDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	topics := map[lifecycle.Kind]string{
+		lifecycle.KindTransfer: o.KafkaTopic,
+		lifecycle.KindConfirm:  o.ConfirmTopic,
+		lifecycle.KindReject:   o.RejectTopic,
+	}
+	var events []lifecycle.Event
+	err = lifecycle.ReplayTopics(ctx, o.Transport, topics, o.Naming.IsOwned, func(e lifecycle.Event) error {
+		e.Message.Files = nil
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Submissions are ordered by date, so the last one of each path is the latest
+	latest := make(map[string]lifecycle.Submission)
+	for _, submission := range lifecycle.Correlate(events) {
+		if submission.Path == "" {
+			continue
+		}
+		path := filepath.Clean(submission.Path)
+		// A resubmitted submission is never the latest, even if dated the same
+		if _, ok := latest[path]; ok && submission.State == lifecycle.Resubmitted {
+			continue
+		}
+		latest[path] = submission
+	}
+
+	var findings []Finding
+	for _, dir := range dirs {
+		if _, ok := latest[dir]; !ok {
+			findings = append(findings, Finding{Kind: NotSubmitted, Path: dir})
+		}
+	}
+
+	roots := o.roots()
+	for path, submission := range latest {
+		if !inRoots(path, roots) {
+			continue
+		}
+		finding := Finding{
+			Path:       path,
+			Identifier: submission.Identifier,
+			Urn:        submission.Urn,
+			State:      submission.State,
+			Date:       submission.Date,
+		}
+		exists, err := isDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each submission is reported at most once. A missing directory
+		// is reported before a missing response or resubmission, which the
+		// state of the finding still shows.
+		switch {
+		case submission.State == lifecycle.Confirmed:
+			if exists {
+				finding.Kind = ConfirmedOnDisk
+			}
+		case !exists:
+			finding.Kind = PathMissing
+		case submission.State == lifecycle.Submitted || submission.State == lifecycle.TimedOut:
+			submittedAt := submission.SubmittedAt()
+			if submittedAt.IsZero() || now.Sub(submittedAt) >= o.MinAge {
+				finding.Kind = NoResponse
+			}
+		case submission.State == lifecycle.Rejected:
+			finding.Kind = RejectedNotResubmitted
+		}
+		if finding.Kind != "" {
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Kind != findings[j].Kind {
+			return findings[i].Kind < findings[j].Kind
+		}
+		return findings[i].Path < findings[j].Path
+	})
+	return findings, nil
+}
+
+// packageDirs returns the cleaned paths of the WARC directories in the send
+// root and the acquisition roots in the acquisition directories.
+func (o ReconcileOptions) packageDirs() ([]string, error) {
+	var dirs []string
+	if o.SendDir != "" {
+		subdirs, err := subdirectories(o.SendDir)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, subdirs...)
+	}
+	for _, dir := range o.AcquisitionDirs {
+		subdirs, err := subdirectories(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, subdir := range subdirs {
+			if _, err := os.Stat(filepath.Join(subdir, acquisitionFileName)); err == nil {
+				dirs = append(dirs, subdir)
+			}
+		}
+	}
+	return dirs, nil
+}
+
+func subdirectories(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory '%s': %w", dir, err)
+	}
+	var subdirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			subdirs = append(subdirs, filepath.Join(filepath.Clean(dir), entry.Name()))
+		}
+	}
+	return subdirs, nil
+}
+
+func isDir(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat '%s': %w", path, err)
+	}
+	return info.IsDir(), nil
+}
+
+func writeCsv(w io.Writer, findings []Finding) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"kind", "path", "identifier", "urn", "state", "date"}); err != nil {
+		return err
+	}
+	for _, f := range findings {
+		if err := writer.Write([]string{f.Kind, f.Path, f.Identifier, f.Urn, string(f.State), f.Date}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
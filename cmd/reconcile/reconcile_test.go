@@ -0,0 +1,130 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	root, err := os.MkdirTemp("", "hermetic-reconcile-")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(root)
+
+	sendDir := filepath.Join(root, "send")
+	acquisitionDir := filepath.Join(root, "acquisition")
+	for _, dir := range []string{
+		filepath.Join(sendDir, "confirmed"),
+		filepath.Join(sendDir, "unsubmitted"),
+		filepath.Join(sendDir, "rejected"),
+		filepath.Join(sendDir, "resubmitted"),
+		filepath.Join(acquisitionDir, "pending"),
+		filepath.Join(acquisitionDir, "other"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(acquisitionDir, "pending", acquisitionFileName), nil, 0o644); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	transport := dps.NewMemoryTransport()
+	send := func(topic string, msg dps.Message) {
+		producer, _ := transport.Producer(topic, dps.KeyRandom)
+		value, _ := json.Marshal(msg)
+		if err := producer.WriteRecords(ctx, dps.Record{Value: value}); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	confirmed := dps.CreateMessage(filepath.Join(sendDir, "confirmed"), "confirmed", dps.ContentTypeWarc)
+	rejected := dps.CreateMessage(filepath.Join(sendDir, "rejected"), "rejected", dps.ContentTypeWarc)
+	original := dps.CreateMessage(filepath.Join(sendDir, "resubmitted"), "resubmitted", dps.ContentTypeWarc)
+	resubmission, err := dps.CreateResubmission(original)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	missing := dps.CreateMessage(filepath.Join(sendDir, "missing"), "missing", dps.ContentTypeWarc)
+	gone := dps.CreateMessage(filepath.Join(sendDir, "gone"), "gone", dps.ContentTypeWarc)
+	pending := dps.CreateMessage(filepath.Join(acquisitionDir, "pending"), "pending", dps.ContentTypeAcquisition)
+	elsewhere := dps.CreateMessage("/elsewhere/a", "a", dps.ContentTypeWarc)
+
+	for _, msg := range []dps.Message{confirmed, rejected, original, resubmission, missing, gone, pending, elsewhere} {
+		send("transfer", msg)
+	}
+	send("confirm", confirmed)
+	send("reject", rejected)
+	send("reject", original)
+	send("reject", gone)
+
+	var out bytes.Buffer
+	o := ReconcileOptions{
+		Transport:       transport,
+		KafkaTopic:      "transfer",
+		ConfirmTopic:    "confirm",
+		RejectTopic:     "reject",
+		SendDir:         sendDir,
+		AcquisitionDirs: []string{acquisitionDir},
+		Format:          outputJson,
+		Naming:          dps.DefaultNamingScheme,
+		Output:          &out,
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var findings []Finding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := []Finding{
+		{Kind: ConfirmedOnDisk, Path: confirmed.Path, Identifier: confirmed.Identifier},
+		{Kind: NoResponse, Path: pending.Path, Identifier: pending.Identifier},
+		{Kind: NoResponse, Path: resubmission.Path, Identifier: resubmission.Identifier},
+		{Kind: NotSubmitted, Path: filepath.Join(sendDir, "unsubmitted")},
+		// Missing directories are not also reported as without a response or rejected
+		{Kind: PathMissing, Path: gone.Path, Identifier: gone.Identifier},
+		{Kind: PathMissing, Path: missing.Path, Identifier: missing.Identifier},
+		{Kind: RejectedNotResubmitted, Path: rejected.Path, Identifier: rejected.Identifier},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings, got %+v", len(expected), findings)
+	}
+	for i, want := range expected {
+		got := findings[i]
+		if got.Kind != want.Kind || got.Path != want.Path || got.Identifier != want.Identifier {
+			t.Errorf("Expected finding %+v, got %+v", want, got)
+		}
+	}
+
+	out.Reset()
+	o.Format = outputCsv
+	o.MinAge = time.Hour
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	// Header, and every finding but those without a response for an hour
+	if len(rows) != 1+len(expected)-2 {
+		t.Errorf("Expected %d rows, got %v", 1+len(expected)-2, rows)
+	}
+	if rows[0][0] != "kind" {
+		t.Errorf("Expected header, got %v", rows[0])
+	}
+}
@@ -0,0 +1,205 @@
+package resubmit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
+	"github.com/nlnwa/hermetic/cmd/internal/cmdutil"
+	"github.com/nlnwa/hermetic/cmd/internal/flags"
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/fixity"
+	"github.com/nlnwa/hermetic/internal/state"
+	"github.com/nlnwa/hermetic/internal/validate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	validateFlagName    string = "validate"
+	validateHelpMessage string = "validate the layout and checksums of a WARC directory before resubmitting it"
+
+	manifestFlagName    string = "manifest"
+	manifestHelpMessage string = "include a manifest with the size, modification time, md5 and sha256 of every file in the message"
+
+	stateFileFlagName    string = "state-file"
+	stateFileHelpMessage string = "optional path to file used to record the resubmission, e.g. the state file of send (which must not be running)"
+)
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(validateFlagName, true, validateHelpMessage)
+	cmd.Flags().Bool(manifestFlagName, false, manifestHelpMessage)
+	cmd.Flags().String(stateFileFlagName, "", stateFileHelpMessage)
+	flags.AddMarkerFileFlag(cmd)
+	flags.AddKafkaKeyStrategyFlag(cmd)
+}
+
+type ResubmitOptions struct {
+	Transport   dps.Transport
+	KafkaTopic  string
+	Query       string
+	Validate    bool
+	Manifest    bool
+	StateFile   string
+	MarkerFile  string
+	KeyStrategy dps.KeyStrategy
+	Naming      dps.NamingScheme
+}
+
+func toOptions(query string) (ResubmitOptions, error) {
+	keyStrategy, err := flags.GetKafkaKeyStrategy()
+	if err != nil {
+		return ResubmitOptions{}, err
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return ResubmitOptions{}, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return ResubmitOptions{}, err
+	}
+
+	return ResubmitOptions{
+		Transport:   transport,
+		KafkaTopic:  flags.GetKafkaTopic(),
+		Query:       query,
+		Validate:    viper.GetBool(validateFlagName),
+		Manifest:    viper.GetBool(manifestFlagName),
+		StateFile:   viper.GetString(stateFileFlagName),
+		MarkerFile:  flags.GetMarkerFile(),
+		KeyStrategy: keyStrategy,
+		Naming:      naming,
+	}, nil
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resubmit <urn|identifier|path>",
+		Short: "Resubmits a package to digital storage",
+		Long: `Resubmits a package to digital storage.
+
+The latest submission matching the given URN, identifier or path is looked up on
+the transfer topic (--kafka-topic), and a new message with a new identifier
+referring to the previous one is sent to the same topic.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			opts, err := toOptions(args[0])
+			if err != nil {
+				return err
+			}
+			return cmdutil.HandleError(opts.Run())
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+func (o ResubmitOptions) Run() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	previous, err := o.findPrevious(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("Found previous submission", "identifier", previous.Identifier, "urn", previous.Urn, "path", previous.Path, "date", previous.Date)
+
+	if o.Validate {
+		if err := o.validatePackage(previous); err != nil {
+			return err
+		}
+	}
+
+	message, err := o.Naming.CreateResubmission(*previous)
+	if err != nil {
+		return fmt.Errorf("failed to create resubmission: %w", err)
+	}
+	if o.Manifest {
+		message.Files, err = dps.CreateManifest(message.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	var store *state.Store
+	if o.StateFile != "" {
+		store, err = state.Open(o.StateFile)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+	}
+
+	producer, err := o.Transport.Producer(o.KafkaTopic, o.KeyStrategy)
+	if err != nil {
+		return err
+	}
+	defer producer.Close()
+
+	if err := dps.Send(ctx, producer, message, o.KeyStrategy); err != nil {
+		return fmt.Errorf("failed to send message to kafka topic '%s': %w", o.KafkaTopic, err)
+	}
+	slog.Info("Resubmitted package", "identifier", message.Identifier, "previousIdentifier", message.PreviousIdentifier, "urn", message.Urn, "path", message.Path)
+
+	if store != nil {
+		if err := store.Put(state.NewRecord(message)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findPrevious returns the latest message on the transfer topic matching the query.
+func (o ResubmitOptions) findPrevious(ctx context.Context) (*dps.Message, error) {
+	var previous *dps.Message
+	err := dps.ReadLatestMessages(ctx, o.Transport, o.KafkaTopic, func(msg *dps.Message) error {
+		if !o.Naming.IsOwnedSubmission(msg) {
+			return nil
+		}
+		if msg.Identifier != o.Query && msg.Urn != o.Query && msg.Path != o.Query {
+			return nil
+		}
+		// Messages from different partitions are not ordered
+		if previous == nil || msg.Date > previous.Date {
+			previous = msg
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latest messages: %w", err)
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("found no submission matching '%s' on kafka topic '%s'", o.Query, o.KafkaTopic)
+	}
+	return previous, nil
+}
+
+// validatePackage checks the directory of msg as send does before sending it.
+func (o ResubmitOptions) validatePackage(msg *dps.Message) error {
+	if msg.ContentType != dps.ContentTypeWarc {
+		slog.Warn("Skipping validation, only supported for WARC directories", "contentType", msg.ContentType, "path", msg.Path)
+		return nil
+	}
+	// The marker file is not part of the payload, so it is allowed in addition to the payload files
+	var extraFiles []string
+	if o.MarkerFile != "" {
+		extraFiles = append(extraFiles, o.MarkerFile)
+	}
+	violations, err := validate.WarcDirectory(msg.Path, extraFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to validate '%s': %w", msg.Path, err)
+	}
+	if err := validate.Error(msg.Path, violations); err != nil {
+		return err
+	}
+	return fixity.VerifyDirectory(msg.Path, extraFiles...)
+}
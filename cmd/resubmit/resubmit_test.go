@@ -0,0 +1,200 @@
+package resubmit
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/fixity"
+	"github.com/nlnwa/hermetic/internal/state"
+)
+
+func sendAll(t *testing.T, transport dps.Transport, messages ...dps.Message) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	producer, err := transport.Producer("transfer", dps.KeyRandom)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for _, msg := range messages {
+		if err := dps.Send(ctx, producer, msg, dps.KeyRandom); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+}
+
+func TestFindPrevious(t *testing.T) {
+	earlier := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	earlier.Date = "2024-01-01T00:00:00.000"
+	// The latest submission is written before the earlier one, as if on another partition
+	latest, err := dps.CreateResubmission(earlier)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	latest.Date = "2024-02-01T00:00:00.000"
+	other := dps.CreateMessage("/root/b", "b", dps.ContentTypeWarc)
+	notOwned := dps.CreateMessage("/root/c", "c", dps.ContentTypeWarc)
+	notOwned.ContentCategory = "other"
+
+	transport := dps.NewMemoryTransport()
+	sendAll(t, transport, latest, earlier, other, notOwned)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"urn", earlier.Urn, latest.Identifier},
+		{"path", earlier.Path, latest.Identifier},
+		{"identifier of earlier", earlier.Identifier, earlier.Identifier},
+		{"identifier of latest", latest.Identifier, latest.Identifier},
+		{"other package", other.Path, other.Identifier},
+		{"not owned", notOwned.Path, ""},
+		{"unknown", "/root/unknown", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := ResubmitOptions{
+				Transport:  transport,
+				KafkaTopic: "transfer",
+				Query:      test.query,
+				Naming:     dps.DefaultNamingScheme,
+			}
+			previous, err := o.findPrevious(context.Background())
+			if test.expected == "" {
+				if err == nil {
+					t.Errorf("Expected error, got %v", previous)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got '%s'", err)
+			}
+			if previous.Identifier != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, previous.Identifier)
+			}
+		})
+	}
+}
+
+func TestResubmit(t *testing.T) {
+	directory, err := os.MkdirTemp("", "resubmit")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "crawl-0001")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	content := []byte("warc")
+	digest := md5.Sum(content)
+	files := map[string][]byte{
+		"crawl-0001.warc.gz":    content,
+		fixity.ChecksumFileName: []byte(hex.EncodeToString(digest[:]) + "  crawl-0001.warc.gz\n"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(path, name), content, 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	previous := dps.CreateMessage(path, "crawl-0001", dps.ContentTypeWarc)
+	transport := dps.NewMemoryTransport()
+	sendAll(t, transport, previous)
+
+	o := ResubmitOptions{
+		Transport:   transport,
+		KafkaTopic:  "transfer",
+		Query:       previous.Urn,
+		Validate:    true,
+		StateFile:   filepath.Join(directory, "state.db"),
+		KeyStrategy: dps.KeyRandom,
+		Naming:      dps.DefaultNamingScheme,
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	records := transport.Records("transfer")
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(records))
+	}
+	var resubmission dps.Message
+	if err := json.Unmarshal(records[1].Value, &resubmission); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if resubmission.PreviousIdentifier != previous.Identifier || resubmission.Identifier == previous.Identifier {
+		t.Errorf("Expected resubmission of %s, got %+v", previous.Identifier, resubmission)
+	}
+
+	store, err := state.Open(o.StateFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	record, err := store.Get(path)
+	// Resubmit opens the state file again below
+	store.Close()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if record == nil || record.Identifier != resubmission.Identifier {
+		t.Errorf("Expected resubmission to be recorded in state, got %v", record)
+	}
+
+	// A package that fails validation is not resubmitted
+	if err := os.WriteFile(filepath.Join(path, "crawl-0001.warc.gz"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := o.Run(); err == nil {
+		t.Errorf("Expected fixity error, got nil")
+	}
+	if n := len(transport.Records("transfer")); n != 2 {
+		t.Errorf("Expected no new messages, got %d", n)
+	}
+}
+
+func TestValidatePackageWithMarkerFile(t *testing.T) {
+	directory, err := os.MkdirTemp("", "resubmit")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "crawl-0001")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	content := []byte("warc")
+	digest := md5.Sum(content)
+	files := map[string][]byte{
+		"crawl-0001.warc.gz":    content,
+		fixity.ChecksumFileName: []byte(hex.EncodeToString(digest[:]) + "  crawl-0001.warc.gz\n"),
+		".complete":             nil,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(path, name), content, 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	msg := dps.CreateMessage(path, "crawl-0001", dps.ContentTypeWarc)
+
+	// The marker file is only allowed when named, as send allows it
+	var o ResubmitOptions
+	if err := o.validatePackage(&msg); err == nil {
+		t.Errorf("Expected unexpected file error, got nil")
+	}
+	o.MarkerFile = ".complete"
+	if err := o.validatePackage(&msg); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
@@ -7,7 +7,13 @@ import (
 
 	"github.com/nlnwa/hermetic/cmd/acquisition"
 	"github.com/nlnwa/hermetic/cmd/internal/flags"
+	"github.com/nlnwa/hermetic/cmd/reconcile"
+	"github.com/nlnwa/hermetic/cmd/resubmit"
+	"github.com/nlnwa/hermetic/cmd/schema"
 	"github.com/nlnwa/hermetic/cmd/send"
+	"github.com/nlnwa/hermetic/cmd/status"
+	"github.com/nlnwa/hermetic/cmd/track"
+	"github.com/nlnwa/hermetic/cmd/validate"
 	"github.com/nlnwa/hermetic/cmd/verify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -37,6 +43,12 @@ func NewRootCommand() *cobra.Command {
 	cmd.AddCommand(send.NewCommand())
 	cmd.AddCommand(verify.NewCommand())
 	cmd.AddCommand(acquisition.NewCommand())
+	cmd.AddCommand(validate.NewCommand())
+	cmd.AddCommand(resubmit.NewCommand())
+	cmd.AddCommand(schema.NewCommand())
+	cmd.AddCommand(track.NewCommand())
+	cmd.AddCommand(status.NewCommand())
+	cmd.AddCommand(reconcile.NewCommand())
 	return cmd
 }
 
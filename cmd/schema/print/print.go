@@ -0,0 +1,54 @@
+package print
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	schemaVersionFlagName    string = "schema-version"
+	schemaVersionHelpMessage string = "version of the schema"
+)
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().String(schemaVersionFlagName, dps.SchemaVersion, schemaVersionHelpMessage)
+}
+
+type PrintOptions struct {
+	Kind    dps.SchemaKind
+	Version string
+	Output  io.Writer
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:       "print <transfer|response>",
+		Short:     "Prints the JSON schema of transfer or response messages",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{string(dps.SchemaTransfer), string(dps.SchemaResponse)},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return PrintOptions{
+				Kind:    dps.SchemaKind(args[0]),
+				Version: viper.GetString(schemaVersionFlagName),
+				Output:  cmd.OutOrStdout(),
+			}.Run()
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+func (o PrintOptions) Run() error {
+	b, err := dps.Schema(o.Kind, o.Version)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(o.Output, string(b))
+	return err
+}
@@ -0,0 +1,17 @@
+package schema
+
+import (
+	"github.com/nlnwa/hermetic/cmd/schema/print"
+	"github.com/nlnwa/hermetic/cmd/schema/validate"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	rootCommand := &cobra.Command{
+		Use:   "schema",
+		Short: "Prints and validates against the JSON schemas of messages",
+	}
+	rootCommand.AddCommand(print.NewCommand())
+	rootCommand.AddCommand(validate.NewCommand())
+	return rootCommand
+}
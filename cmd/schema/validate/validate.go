@@ -0,0 +1,112 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	schemaVersionFlagName    string = "schema-version"
+	schemaVersionHelpMessage string = "version of the schema"
+)
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().String(schemaVersionFlagName, dps.SchemaVersion, schemaVersionHelpMessage)
+}
+
+type ValidateOptions struct {
+	Kind    dps.SchemaKind
+	Version string
+	Files   []string
+	Input   io.Reader
+	Output  io.Writer
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate <transfer|response> [file...]",
+		Short: "Validates JSON documents against the schema of transfer or response messages",
+		Long: `Validates JSON documents against the schema of transfer or response messages.
+
+Each file may contain several documents, e.g. JSON lines as printed by 'send --dry-run'.
+Documents are read from stdin if no file is given, or if the file is '-'.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind := dps.SchemaKind(args[0])
+			if kind != dps.SchemaTransfer && kind != dps.SchemaResponse {
+				return fmt.Errorf("unknown schema '%s', expected '%s' or '%s'", kind, dps.SchemaTransfer, dps.SchemaResponse)
+			}
+			return ValidateOptions{
+				Kind:    kind,
+				Version: viper.GetString(schemaVersionFlagName),
+				Files:   args[1:],
+				Input:   cmd.InOrStdin(),
+				Output:  cmd.OutOrStdout(),
+			}.Run()
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+func (o ValidateOptions) Run() error {
+	files := o.Files
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	invalid := 0
+	for _, file := range files {
+		n, err := o.validateFile(file)
+		if err != nil {
+			return err
+		}
+		invalid += n
+	}
+	if invalid > 0 {
+		return fmt.Errorf("found %d invalid document(s)", invalid)
+	}
+	return nil
+}
+
+// validateFile validates every document in file and returns the number of invalid documents.
+func (o ValidateOptions) validateFile(file string) (int, error) {
+	r := o.Input
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	invalid := 0
+	decoder := json.NewDecoder(r)
+	for i := 0; ; i++ {
+		var doc json.RawMessage
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return invalid, nil
+		}
+		if err != nil {
+			return invalid, fmt.Errorf("%s: document %d: failed to parse JSON: %w", file, i, err)
+		}
+		if err := dps.ValidateSchema(o.Kind, o.Version, bytes.TrimSpace(doc)); err != nil {
+			invalid++
+			fmt.Fprintf(o.Output, "%s: document %d: %s\n", file, i, err)
+			continue
+		}
+		fmt.Fprintf(o.Output, "%s: document %d: ok\n", file, i)
+	}
+}
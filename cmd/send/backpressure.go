@@ -0,0 +1,141 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/state"
+)
+
+// inFlight limits the number of submissions awaiting a confirm or reject
+// message from DPS. Submissions in flight are persisted in the state store so
+// that the limit survives restarts. Submissions that have awaited a response
+// longer than ttl no longer count, so lost responses do not shrink the limit.
+type inFlight struct {
+	store *state.Store
+	limit int
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	count   int
+	changed chan struct{}
+}
+
+// newInFlight returns a limit of submissions in flight, where ttl zero means
+// that submissions are in flight until a response arrives.
+func newInFlight(store *state.Store, limit int, ttl time.Duration) (*inFlight, error) {
+	count, err := store.LenInFlight()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count submissions in flight: %w", err)
+	}
+	return &inFlight{
+		store:   store,
+		limit:   limit,
+		ttl:     ttl,
+		count:   count,
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// Wait blocks until fewer than limit submissions are in flight.
+func (f *inFlight) Wait(ctx context.Context) error {
+	logged := false
+	for {
+		if err := f.expire(time.Now()); err != nil {
+			return err
+		}
+
+		f.mu.Lock()
+		count, changed := f.count, f.changed
+		f.mu.Unlock()
+
+		if count < f.limit {
+			return nil
+		}
+		if !logged {
+			slog.Info("Limit of submissions in flight reached, waiting for responses from DPS", "inFlight", count, "limit", f.limit)
+			logged = true
+		}
+		var expiry <-chan time.Time
+		if f.ttl > 0 {
+			expiry = time.After(min(f.ttl, time.Minute))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-expiry:
+		}
+	}
+}
+
+// expire stops counting submissions that have been in flight longer than the ttl.
+func (f *inFlight) expire(now time.Time) error {
+	if f.ttl <= 0 {
+		return nil
+	}
+	expired, err := f.store.ExpireInFlight(now.Add(-f.ttl))
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+	slog.Warn("Submissions got no response in time and no longer count as in flight", "identifiers", expired, "ttl", f.ttl)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count -= len(expired)
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return nil
+}
+
+func (f *inFlight) Add(identifier string, path string) error {
+	if err := f.store.PutInFlight(identifier, path, time.Now()); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count++
+	return nil
+}
+
+func (f *inFlight) Done(identifier string) error {
+	found, err := f.store.DeleteInFlight(identifier)
+	if err != nil || !found {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count--
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return nil
+}
+
+// Consume marks submissions as done as responses arrive on the given topic,
+// until ctx is cancelled or reading fails. Only responses accepted by filter
+// are considered, and responses that can not be processed are handed to
+// deadLetters, if not nil.
+func (f *inFlight) Consume(ctx context.Context, transport dps.Transport, topic string, groupID string, filter func(*dps.Message) bool, deadLetters *dps.DeadLetters) error {
+	consumer, err := transport.Consumer(topic, groupID)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	for {
+		message, err := dps.NextMessage(ctx, consumer, filter, deadLetters)
+		if err != nil {
+			return fmt.Errorf("failed to read next message from kafka topic '%s': %w", topic, err)
+		}
+		if err := f.Done(message.Value.Identifier); err != nil {
+			return err
+		}
+		if err := consumer.CommitRecords(ctx, message.Record); err != nil {
+			return fmt.Errorf("failed to commit message at offset %d: %w", message.Offset, err)
+		}
+	}
+}
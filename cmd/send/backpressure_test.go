@@ -0,0 +1,96 @@
+package send
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/state"
+)
+
+func TestInFlightWait(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	store, err := state.Open(filepath.Join(directory, "state.db"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+
+	f, err := newInFlight(store, 1, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := f.Add("identifier", "/root/dir"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- f.Wait(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Expected Wait to block while limit is reached, got '%v'", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := f.Done("identifier"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestInFlightExpire(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	store, err := state.Open(filepath.Join(directory, "state.db"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+
+	// A response to this submission was lost before the restart
+	if err := store.PutInFlight("lost", "/root/lost", time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	f, err := newInFlight(store, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("Expected lost submission to expire, got '%s'", err)
+	}
+	n, err := store.LenInFlight()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected no submissions in flight, got %d", n)
+	}
+}
@@ -0,0 +1,36 @@
+package send
+
+import (
+	"github.com/nlnwa/hermetic/internal/state"
+)
+
+type sentStore interface {
+	Has(path string) (bool, error)
+	Put(record state.Record) error
+}
+
+// dryRunStore keeps new records in memory so that a dry run never modifies
+// the state file.
+type dryRunStore struct {
+	base *state.Store
+	sent map[string]bool
+}
+
+func newDryRunStore(base *state.Store) *dryRunStore {
+	return &dryRunStore{base: base, sent: make(map[string]bool)}
+}
+
+func (s *dryRunStore) Has(path string) (bool, error) {
+	if s.sent[path] {
+		return true, nil
+	}
+	if s.base == nil {
+		return false, nil
+	}
+	return s.base.Has(path)
+}
+
+func (s *dryRunStore) Put(record state.Record) error {
+	s.sent[record.Path] = true
+	return nil
+}
@@ -0,0 +1,81 @@
+package send
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// entryPolicy decides what to do with unexpected entries in the root directory.
+type entryPolicy string
+
+const (
+	entryPolicyIgnore     entryPolicy = "ignore"
+	entryPolicyWarn       entryPolicy = "warn"
+	entryPolicyQuarantine entryPolicy = "quarantine"
+)
+
+func parseEntryPolicy(s string) (entryPolicy, error) {
+	switch p := entryPolicy(s); p {
+	case entryPolicyIgnore, entryPolicyWarn, entryPolicyQuarantine:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown policy '%s' for unexpected entries, expected one of '%s', '%s' or '%s'", s, entryPolicyIgnore, entryPolicyWarn, entryPolicyQuarantine)
+	}
+}
+
+// unexpectedEntry returns why the entry at path is not a payload directory,
+// or the empty string if it is.
+func unexpectedEntry(path string, entry fs.DirEntry) string {
+	switch {
+	case entry.Type()&fs.ModeSymlink != 0:
+		return "symbolic link"
+	case entry.Type().IsRegular():
+		return "regular file"
+	case !entry.IsDir():
+		return fmt.Sprintf("unsupported file type '%s'", entry.Type())
+	}
+
+	items, err := os.ReadDir(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Removed since the root directory was read, which the scan skips
+		return ""
+	}
+	if err != nil {
+		return fmt.Sprintf("malformed directory: %s", err)
+	}
+	for _, item := range items {
+		if item.IsDir() {
+			return fmt.Sprintf("malformed directory: contains subdirectory '%s'", item.Name())
+		}
+	}
+	return ""
+}
+
+// quarantine moves path into dir, adding a timestamp to the name if dir
+// already holds an entry with the same name.
+func quarantine(path string, dir string) (string, error) {
+	target := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Lstat(target); err == nil {
+		target = fmt.Sprintf("%s.%s", target, time.Now().UTC().Format("20060102T150405.000000000"))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err := os.Rename(path, target); err != nil {
+		return "", fmt.Errorf("failed to move '%s' to quarantine: %w", path, err)
+	}
+	return target, nil
+}
+
+// isWithin reports whether path is dir or located below dir.
+func isWithin(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,118 @@
+package send
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlnwa/hermetic/internal/readiness"
+)
+
+func TestUnexpectedEntry(t *testing.T) {
+	root, err := os.MkdirTemp("", "root")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"payload", "nested", filepath.Join("nested", "child")} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "file.txt"), nil, 0644); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "payload"), filepath.Join(root, "link")); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	items, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for _, entry := range items {
+		reason := unexpectedEntry(filepath.Join(root, entry.Name()), entry)
+		if entry.Name() == "payload" && reason != "" {
+			t.Errorf("Expected '%s' to be expected, got '%s'", entry.Name(), reason)
+		}
+		if entry.Name() != "payload" && reason == "" {
+			t.Errorf("Expected '%s' to be unexpected", entry.Name())
+		}
+	}
+
+	// A directory removed since the root was read is left to the scan to skip
+	for _, entry := range items {
+		if entry.Name() != "payload" {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		if err := os.Remove(path); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if reason := unexpectedEntry(path, entry); reason != "" {
+			t.Errorf("Expected removed '%s' not to be unexpected, got '%s'", entry.Name(), reason)
+		}
+		if _, err := readiness.LastModified(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected not exist error, got '%v'", err)
+		}
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	root, err := os.MkdirTemp("", "root")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(root)
+
+	quarantineDir := filepath.Join(root, "quarantine")
+	if err := os.Mkdir(quarantineDir, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(root, "file.txt")
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		target, err := quarantine(path, quarantineDir)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if filepath.Dir(target) != quarantineDir {
+			t.Errorf("Expected '%s' to be in '%s'", target, quarantineDir)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected '%s' to be moved", path)
+		}
+	}
+
+	items, err := os.ReadDir(quarantineDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("Expected 2 quarantined files, got %d", len(items))
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		path     string
+		dir      string
+		expected bool
+	}{
+		{"/root", "/root", true},
+		{"/root/quarantine", "/root", true},
+		{"/quarantine", "/root", false},
+		{"/rootquarantine", "/root", false},
+	}
+	for _, test := range tests {
+		if actual := isWithin(test.path, test.dir); actual != test.expected {
+			t.Errorf("Expected isWithin('%s', '%s') to be %t, got %t", test.path, test.dir, test.expected, actual)
+		}
+	}
+}
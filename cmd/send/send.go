@@ -2,8 +2,11 @@ package send
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -13,11 +16,16 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/allegro/bigcache/v3"
 	"github.com/nlnwa/hermetic/cmd/internal/cmdutil"
 	"github.com/nlnwa/hermetic/cmd/internal/flags"
 	"github.com/nlnwa/hermetic/internal/dps"
-	"github.com/segmentio/kafka-go"
+	"github.com/nlnwa/hermetic/internal/fixity"
+	"github.com/nlnwa/hermetic/internal/readiness"
+	"github.com/nlnwa/hermetic/internal/state"
+	"github.com/nlnwa/hermetic/internal/teams"
+	"github.com/nlnwa/hermetic/internal/validate"
+	"github.com/nlnwa/hermetic/internal/warc"
+	"github.com/nlnwa/hermetic/internal/watch"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -35,7 +43,57 @@ const (
 ... etc`
 
 	excludeFlagName    string = "exclude"
-	excludeHelpMessage string = `comma separated list of regular expressions to match directories that should be excluded from preloading to cache`
+	excludeHelpMessage string = `comma separated list of regular expressions to match directories that should be excluded when reconciling state with the kafka topic`
+
+	stateFileFlagName    string = "state-file"
+	stateFileHelpMessage string = `path to file used to persist which directories have been sent`
+
+	reconcileFlagName    string = "reconcile"
+	reconcileHelpMessage string = `replay the kafka topic at startup to reconcile the state file with messages already sent (always done when the state file is empty)`
+
+	verifyFixityFlagName    string = "verify-fixity"
+	verifyFixityHelpMessage string = `verify the digests in checksum_transferred.md5 before sending a directory`
+
+	quiescenceFlagName    string = "quiescence"
+	quiescenceHelpMessage string = `period a directory must go without modifications before it is sent`
+
+	requiredFilesFlagName    string = "required-files"
+	requiredFilesHelpMessage string = `comma separated list of glob patterns that must each match a file in a directory before it is sent, where '{dir}' is replaced by the directory name`
+
+	watchFlagName    string = "watch"
+	watchHelpMessage string = `watch the root directory for new directories instead of relying on periodic rescans only`
+
+	rescanIntervalFlagName    string = "rescan-interval"
+	rescanIntervalHelpMessage string = `interval between full rescans of the root directory`
+
+	onceFlagName    string = "once"
+	onceHelpMessage string = `scan the root directory once, send what is ready and exit`
+
+	dryRunFlagName    string = "dry-run"
+	dryRunHelpMessage string = `scan the root directory once and print the messages that would be sent as JSON lines, without writing to the kafka topic or the state file`
+
+	unexpectedEntriesFlagName    string = "unexpected-entries"
+	unexpectedEntriesHelpMessage string = `what to do with files, symbolic links and malformed directories in the root directory: 'ignore', 'warn' or 'quarantine'`
+
+	quarantineDirFlagName    string = "quarantine-dir"
+	quarantineDirHelpMessage string = `directory outside the root directory to move unexpected entries to when using --unexpected-entries=quarantine`
+
+	validateFlagName    string = "validate"
+	validateHelpMessage string = `validate the layout of a directory before sending it`
+
+	verifyWarcFlagName    string = "verify-warc"
+	verifyWarcHelpMessage string = `verify that every gzip member and WARC record header of the WARC file is intact before sending a directory`
+
+	manifestFlagName    string = "manifest"
+	manifestHelpMessage string = `include a manifest with the size, modification time, md5 and sha256 of every file in the message`
+
+	maxInFlightFlagName    string = "max-in-flight"
+	maxInFlightHelpMessage string = `maximum number of submissions awaiting a confirm or reject message from DPS, 0 means no limit (requires --confirm-topic and --reject-topic)`
+
+	inFlightGroupIDFlagName    string = "in-flight-consumer-group-id"
+	inFlightGroupIDHelpMessage string = `consumer group ID for reading responses with --max-in-flight, must differ from the group of verify and track`
+	inFlightTTLFlagName        string = "in-flight-ttl"
+	inFlightTTLHelpMessage     string = `time after which a submission without a response no longer counts towards --max-in-flight, 0 means never`
 )
 
 func addFlags(cmd *cobra.Command) {
@@ -44,6 +102,27 @@ func addFlags(cmd *cobra.Command) {
 		panic(err)
 	}
 	cmd.Flags().StringSlice(excludeFlagName, nil, excludeHelpMessage)
+	cmd.Flags().String(stateFileFlagName, "hermetic-send.db", stateFileHelpMessage)
+	cmd.Flags().Bool(reconcileFlagName, false, reconcileHelpMessage)
+	cmd.Flags().Bool(verifyFixityFlagName, true, verifyFixityHelpMessage)
+	cmd.Flags().Duration(quiescenceFlagName, 5*time.Minute, quiescenceHelpMessage)
+	flags.AddMarkerFileFlag(cmd)
+	cmd.Flags().StringSlice(requiredFilesFlagName, []string{readiness.DirPlaceholder + ".warc.gz", fixity.ChecksumFileName}, requiredFilesHelpMessage)
+	cmd.Flags().Bool(watchFlagName, true, watchHelpMessage)
+	cmd.Flags().Duration(rescanIntervalFlagName, 1*time.Minute, rescanIntervalHelpMessage)
+	cmd.Flags().Bool(onceFlagName, false, onceHelpMessage)
+	cmd.Flags().Bool(dryRunFlagName, false, dryRunHelpMessage)
+	cmd.Flags().String(unexpectedEntriesFlagName, string(entryPolicyWarn), unexpectedEntriesHelpMessage)
+	cmd.Flags().String(quarantineDirFlagName, "", quarantineDirHelpMessage)
+	cmd.Flags().Bool(validateFlagName, true, validateHelpMessage)
+	cmd.Flags().Bool(verifyWarcFlagName, false, verifyWarcHelpMessage)
+	cmd.Flags().Bool(manifestFlagName, false, manifestHelpMessage)
+	cmd.Flags().Int(maxInFlightFlagName, 0, maxInFlightHelpMessage)
+	cmd.Flags().String(inFlightGroupIDFlagName, "", inFlightGroupIDHelpMessage)
+	cmd.Flags().Duration(inFlightTTLFlagName, 24*time.Hour, inFlightTTLHelpMessage)
+	flags.AddResponseTopicFlags(cmd)
+	flags.AddDeadLetterFlags(cmd)
+	flags.AddKafkaKeyStrategyFlag(cmd)
 }
 
 func toOptions() (SendOptions, error) {
@@ -58,20 +137,118 @@ func toOptions() (SendOptions, error) {
 		exclude = append(exclude, r)
 	}
 
+	readinessPolicy := readiness.Policy{
+		Quiescence:    viper.GetDuration(quiescenceFlagName),
+		MarkerFile:    flags.GetMarkerFile(),
+		RequiredFiles: viper.GetStringSlice(requiredFilesFlagName),
+	}
+	if err := readinessPolicy.Validate(); err != nil {
+		return SendOptions{}, err
+	}
+
+	unexpectedEntries, err := parseEntryPolicy(viper.GetString(unexpectedEntriesFlagName))
+	if err != nil {
+		return SendOptions{}, err
+	}
+	dir := viper.GetString(dirFlagName)
+	quarantineDir := viper.GetString(quarantineDirFlagName)
+	if unexpectedEntries == entryPolicyQuarantine {
+		if quarantineDir == "" {
+			return SendOptions{}, fmt.Errorf("--%s is required when unexpected entries are quarantined", quarantineDirFlagName)
+		}
+		if isWithin(quarantineDir, dir) {
+			return SendOptions{}, fmt.Errorf("quarantine directory '%s' must be outside of root directory '%s'", quarantineDir, dir)
+		}
+	}
+
+	maxInFlight := viper.GetInt(maxInFlightFlagName)
+	if maxInFlight < 0 {
+		return SendOptions{}, fmt.Errorf("--%s must not be negative", maxInFlightFlagName)
+	}
+	if maxInFlight > 0 && (flags.GetConfirmTopic() == "" || flags.GetRejectTopic() == "") {
+		return SendOptions{}, fmt.Errorf("confirm and reject topics are required when --%s is set", maxInFlightFlagName)
+	}
+	if viper.GetDuration(inFlightTTLFlagName) < 0 {
+		return SendOptions{}, fmt.Errorf("--%s must not be negative", inFlightTTLFlagName)
+	}
+
+	keyStrategy, err := flags.GetKafkaKeyStrategy()
+	if err != nil {
+		return SendOptions{}, err
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return SendOptions{}, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return SendOptions{}, err
+	}
+	var deadLetters *dps.DeadLetters
+	if maxInFlight > 0 {
+		deadLetters, err = flags.GetDeadLetters(transport)
+		if err != nil {
+			return SendOptions{}, err
+		}
+	}
+
 	return SendOptions{
-		KafkaEndpoints: flags.GetKafkaEndpoints(),
-		KafkaTopic:     flags.GetKafkaTopic(),
-		Dir:            viper.GetString(dirFlagName),
-		Exclude:        exclude,
+		Transport:         transport,
+		KafkaTopic:        flags.GetKafkaTopic(),
+		Dir:               dir,
+		Exclude:           exclude,
+		StateFile:         viper.GetString(stateFileFlagName),
+		Reconcile:         viper.GetBool(reconcileFlagName),
+		VerifyFixity:      viper.GetBool(verifyFixityFlagName),
+		Readiness:         readinessPolicy,
+		Watch:             viper.GetBool(watchFlagName),
+		RescanInterval:    viper.GetDuration(rescanIntervalFlagName),
+		Once:              viper.GetBool(onceFlagName),
+		DryRun:            viper.GetBool(dryRunFlagName),
+		UnexpectedEntries: unexpectedEntries,
+		QuarantineDir:     quarantineDir,
+		Validate:          viper.GetBool(validateFlagName),
+		VerifyWarc:        viper.GetBool(verifyWarcFlagName),
+		Manifest:          viper.GetBool(manifestFlagName),
+		MaxInFlight:       maxInFlight,
+		DeadLetters:       deadLetters,
+		ConfirmTopic:      flags.GetConfirmTopic(),
+		RejectTopic:       flags.GetRejectTopic(),
+		InFlightGroupID:   viper.GetString(inFlightGroupIDFlagName),
+		InFlightTTL:       viper.GetDuration(inFlightTTLFlagName),
+		KeyStrategy:       keyStrategy,
+		Naming:            naming,
 	}, nil
 }
 
 type SendOptions struct {
-	KafkaTopic      string
-	KafkaEndpoints  []string
-	TeamsWebhookUrl string
-	Dir             string
-	Exclude         []*regexp.Regexp
+	KafkaTopic        string
+	Transport         dps.Transport
+	TeamsWebhookUrl   string
+	Dir               string
+	Exclude           []*regexp.Regexp
+	StateFile         string
+	Reconcile         bool
+	VerifyFixity      bool
+	Readiness         readiness.Policy
+	Watch             bool
+	RescanInterval    time.Duration
+	Once              bool
+	DryRun            bool
+	Output            io.Writer
+	UnexpectedEntries entryPolicy
+	QuarantineDir     string
+	Validate          bool
+	VerifyWarc        bool
+	Manifest          bool
+	MaxInFlight       int
+	DeadLetters       *dps.DeadLetters
+	ConfirmTopic      string
+	RejectTopic       string
+	InFlightGroupID   string
+	InFlightTTL       time.Duration
+	KeyStrategy       dps.KeyStrategy
+	Naming            dps.NamingScheme
 }
 
 func NewCommand() *cobra.Command {
@@ -80,10 +257,19 @@ func NewCommand() *cobra.Command {
 		Short: "Continuously sends data to digital storage",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
 			opts, err := toOptions()
 			if err != nil {
 				return err
 			}
+			if opts.DryRun {
+				// Keep stdout for the messages that would have been sent
+				slog.SetDefault(slog.New(slog.NewJSONHandler(cmd.ErrOrStderr(), nil)))
+				opts.Output = cmd.OutOrStdout()
+				return opts.Run()
+			}
 			return cmdutil.HandleError(opts.Run())
 		},
 	}
@@ -92,83 +278,391 @@ func NewCommand() *cobra.Command {
 	return cmd
 }
 
+// summary counts the outcome of scanning the root directory.
+type summary struct {
+	Sent       int
+	Skipped    int
+	Pending    int
+	Failed     int
+	Unexpected int
+}
+
 func (o SendOptions) Run() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	writer := &kafka.Writer{
-		Addr:     kafka.TCP(o.KafkaEndpoints...),
-		Topic:    o.KafkaTopic,
-		Balancer: &kafka.LeastBytes{},
-	}
-	defer writer.Close()
+	var store sentStore
+	var reconcile bool
+	var limiter *inFlight
+	if o.DryRun {
+		var base *state.Store
+		if _, err := os.Stat(o.StateFile); err == nil {
+			// A snapshot, so the lock of the state file is not held during the pass
+			base, err = state.OpenSnapshot(o.StateFile)
+			if err != nil {
+				return err
+			}
+			defer base.Close()
+		}
+		store = newDryRunStore(base)
+		reconcile = o.Reconcile || base == nil
+	} else {
+		s, err := state.Open(o.StateFile)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		n, err := s.Len()
+		if err != nil {
+			return fmt.Errorf("failed to count records in state file: %w", err)
+		}
+		slog.Info("Opened state file", "path", o.StateFile, "records", n)
+
+		store = s
+		reconcile = o.Reconcile || n == 0
 
-	config := bigcache.Config{
-		Shards:      1024,
-		LifeWindow:  24 * 7 * time.Hour,
-		CleanWindow: 1 * time.Hour,
+		if o.MaxInFlight > 0 {
+			limiter, err = newInFlight(s, o.MaxInFlight, o.InFlightTTL)
+			if err != nil {
+				return err
+			}
+		}
 	}
-	cache, err := bigcache.New(ctx, config)
-	if err != nil {
-		return fmt.Errorf("failed to create cache: %w", err)
+
+	if reconcile {
+		if err := o.reconcile(ctx, store); err != nil {
+			return err
+		}
 	}
 
-	loadCache := func(msg *dps.Message) error {
-		// Skip messages that are not web archive messages
-		if !dps.IsWebArchiveMessage(msg) {
+	// consumerErr returns the error that stopped a consumer of responses from DPS, if any
+	consumerErr := func() error { return nil }
+	if limiter != nil {
+		if o.DeadLetters != nil {
+			defer o.DeadLetters.Close()
+		}
+		consumeCtx, stopConsumers := context.WithCancel(ctx)
+		defer stopConsumers()
+
+		errs := make(chan error, 2)
+		for _, topic := range []string{o.ConfirmTopic, o.RejectTopic} {
+			go func() {
+				errs <- limiter.Consume(consumeCtx, o.Transport, topic, o.InFlightGroupID, o.Naming.IsOwned, o.DeadLetters)
+				stopConsumers()
+			}()
+		}
+		consumerErr = func() error {
+			select {
+			case err := <-errs:
+				if !errors.Is(err, context.Canceled) {
+					return err
+				}
+			default:
+			}
 			return nil
 		}
-		// Skip messages that are not from the root directory
-		if !strings.HasPrefix(msg.Path, o.Dir) {
+		ctx = consumeCtx
+	}
+
+	var send func(context.Context, dps.Message) error
+	if o.DryRun {
+		send = func(_ context.Context, msg dps.Message) error {
+			value, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			if err := dps.ValidateSchema(dps.SchemaTransfer, dps.SchemaVersion, value); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(o.Output, string(value))
+			return err
+		}
+	} else {
+		producer, err := o.Transport.Producer(o.KafkaTopic, o.KeyStrategy)
+		if err != nil {
+			return err
+		}
+		defer producer.Close()
+
+		send = func(ctx context.Context, msg dps.Message) error {
+			if err := dps.Send(ctx, producer, msg, o.KeyStrategy); err != nil {
+				return fmt.Errorf("failed to send message to kafka topic '%s': %w", o.KafkaTopic, err)
+			}
 			return nil
 		}
-		// Skip messages that are excluded explicitly
-		for _, re := range o.Exclude {
-			if re.MatchString(msg.Path) {
-				return nil
+	}
+
+	// The marker file is not part of the payload, so it is allowed in addition to the payload files
+	var extraFiles []string
+	if o.Readiness.MarkerFile != "" {
+		extraFiles = append(extraFiles, o.Readiness.MarkerFile)
+	}
+
+	// Directories that failed the checks, with their last modification time.
+	// They are retried once they have been modified, e.g. when a checksum file
+	// has been fixed.
+	failed := make(map[string]time.Time)
+	markFailed := func(path string) {
+		modTime, err := readiness.LastModified(path)
+		if err != nil {
+			slog.Warn("Failed to read modification time of failed directory", "path", path, "error", err)
+		}
+		failed[path] = modTime
+	}
+	// Directories that are not yet ready to be sent, with the reason why
+	pending := make(map[string]string)
+	// Unexpected entries that have already been reported, with the reason why
+	reported := make(map[string]string)
+
+	handleUnexpected := func(path string, reason string) {
+		if reported[path] == reason {
+			return
+		}
+		reported[path] = reason
+
+		action := "skipped"
+		if o.UnexpectedEntries == entryPolicyQuarantine {
+			if o.DryRun {
+				action = "would be moved to quarantine"
+			} else if target, err := quarantine(path, o.QuarantineDir); err != nil {
+				slog.Error("Failed to quarantine unexpected entry", "path", path, "error", err)
+				action = fmt.Sprintf("skipped, quarantine failed: %s", err)
+			} else {
+				action = fmt.Sprintf("moved to '%s'", target)
+				delete(reported, path)
 			}
 		}
-		if err := cache.Set(msg.Path, []byte("Sent")); err != nil {
-			return fmt.Errorf("failed to set '%s' in cache: %w", msg.Path, err)
+
+		if o.UnexpectedEntries == entryPolicyIgnore {
+			slog.Debug("Ignoring unexpected entry", "path", path, "reason", reason)
+			return
+		}
+		slog.Warn("Found unexpected entry", "path", path, "reason", reason, "action", action)
+		if !o.DryRun {
+			cmdutil.Notify(teams.UnexpectedEntry(path, reason, action))
 		}
-		return nil
 	}
 
-	err = dps.ReadLatestMessages(ctx, o.KafkaEndpoints, o.KafkaTopic, loadCache)
-	if err != nil {
-		return fmt.Errorf("failed to read latest messages: %w", err)
+	// removed reports whether err is because path was removed during the scan,
+	// e.g. moved away once confirmed, in which case it is forgotten and skipped
+	removed := func(path string, err error) bool {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false
+		}
+		slog.Info("Skipping entry removed during scan", "path", path)
+		delete(failed, path)
+		delete(pending, path)
+		delete(reported, path)
+		return true
 	}
 
-	for {
+	scan := func(ctx context.Context) (summary, error) {
+		var sum summary
 		items, err := os.ReadDir(o.Dir)
 		if err != nil {
-			return fmt.Errorf("failed to read root path '%s': %w", o.Dir, err)
+			return sum, fmt.Errorf("failed to read root path '%s': %w", o.Dir, err)
 		}
 		for _, entry := range items {
-			if !entry.IsDir() {
-				return fmt.Errorf("found file '%s', but expected only directories", filepath.Join(o.Dir, entry.Name()))
+			path := filepath.Join(o.Dir, entry.Name())
+			sent, err := store.Has(path)
+			if err != nil {
+				return sum, err
+			}
+			if sent {
+				sum.Skipped++
+				continue
+			}
+			if failedAt, ok := failed[path]; ok {
+				modTime, err := readiness.LastModified(path)
+				if removed(path, err) {
+					continue
+				}
+				if err != nil {
+					return sum, err
+				}
+				if modTime.Equal(failedAt) {
+					sum.Failed++
+					continue
+				}
+				slog.Info("Retrying directory that has been modified since it failed", "path", path)
+				delete(failed, path)
 			}
+			if reason := unexpectedEntry(path, entry); reason != "" {
+				handleUnexpected(path, reason)
+				sum.Unexpected++
+				continue
+			}
+			delete(reported, path)
 
-			path := filepath.Join(o.Dir, entry.Name())
-			_, err := cache.Get(path)
-			if err == nil {
+			status, err := o.Readiness.Check(path, time.Now())
+			if removed(path, err) {
 				continue
 			}
-			if !errors.Is(err, bigcache.ErrEntryNotFound) {
-				return fmt.Errorf("failed to get '%s' from cache: %w", path, err)
+			if err != nil {
+				return sum, fmt.Errorf("failed to check if '%s' is ready: %w", path, err)
+			}
+			if !status.Ready {
+				if pending[path] != status.Reason {
+					slog.Info("Directory is pending", "path", path, "reason", status.Reason)
+				}
+				pending[path] = status.Reason
+				sum.Pending++
+				continue
 			}
+			delete(pending, path)
 
 			slog.Info("Processing directory", "path", path)
 
-			msg := dps.CreateMessage(path, entry.Name(), dps.ContentTypeWarc)
+			if o.Validate {
+				violations, err := validate.WarcDirectory(path, extraFiles...)
+				if removed(path, err) {
+					continue
+				}
+				if err != nil {
+					return sum, fmt.Errorf("failed to validate '%s': %w", path, err)
+				}
+				if err := validate.Error(path, violations); err != nil {
+					slog.Error("Skipping directory that failed validation", "path", path, "violations", violations)
+					if !o.DryRun {
+						cmdutil.Notify(teams.ValidationError(path, violations))
+					}
+					markFailed(path)
+					sum.Failed++
+					continue
+				}
+			}
 
-			if err := dps.Send(ctx, writer, msg); err != nil {
-				return fmt.Errorf("failed to send message to kafka topic '%s': %w", o.KafkaTopic, err)
+			if o.VerifyFixity {
+				if err := fixity.VerifyDirectory(path, extraFiles...); err != nil {
+					slog.Error("Skipping directory that failed fixity check", "path", path, "error", err)
+					if !o.DryRun {
+						cmdutil.NotifyError(err)
+					}
+					markFailed(path)
+					sum.Failed++
+					continue
+				}
+			}
+
+			if o.VerifyWarc {
+				if err := verifyWarc(path); err != nil {
+					slog.Error("Skipping directory that failed WARC integrity check", "path", path, "error", err)
+					if !o.DryRun {
+						cmdutil.NotifyError(err)
+					}
+					markFailed(path)
+					sum.Failed++
+					continue
+				}
+			}
+
+			msg := o.Naming.CreateMessage(path, entry.Name(), dps.ContentTypeWarc)
+			if o.Manifest {
+				msg.Files, err = dps.CreateManifest(path)
+				if removed(path, err) {
+					continue
+				}
+				if err != nil {
+					return sum, err
+				}
+			}
+
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return sum, err
+				}
+				if err := limiter.Add(msg.Identifier, msg.Path); err != nil {
+					return sum, err
+				}
+			}
+			if err := send(ctx, msg); err != nil {
+				if limiter != nil {
+					_ = limiter.Done(msg.Identifier)
+				}
+				return sum, err
+			}
+			if err := store.Put(state.NewRecord(msg)); err != nil {
+				return sum, err
 			}
-			if err := cache.Set(path, []byte("Sent")); err != nil {
-				return fmt.Errorf("failed to set '%s' in cache: %w", path, err)
+			sum.Sent++
+		}
+		return sum, nil
+	}
+
+	if o.Once || o.DryRun {
+		sum, err := scan(ctx)
+		if err := consumerErr(); err != nil {
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		slog.Info("Scan complete", "dryRun", o.DryRun, "sent", sum.Sent, "skipped", sum.Skipped, "pending", sum.Pending, "failed", sum.Failed, "unexpected", sum.Unexpected)
+		if sum.Failed > 0 {
+			return fmt.Errorf("%d directories failed checks", sum.Failed)
+		}
+		return nil
+	}
+
+	err := watch.Watch(ctx, o.Dir, o.RescanInterval, o.Watch, func(ctx context.Context) error {
+		_, err := scan(ctx)
+		return err
+	})
+	if err := consumerErr(); err != nil {
+		return err
+	}
+	return err
+}
+
+// verifyWarc checks the integrity of the WARC files in dir and logs a report per file.
+func verifyWarc(dir string) error {
+	reports, err := warc.CheckDirectory(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check WARC files in '%s': %w", dir, err)
+	}
+	var errs []error
+	for _, report := range reports {
+		slog.Info("Checked WARC file", "path", report.Path, "members", report.Members, "records", report.Records, "error", report.Error)
+		errs = append(errs, report.Err())
+	}
+	return errors.Join(errs...)
+}
+
+// reconcile adds messages found on the kafka topic that are missing from the state store.
+func (o SendOptions) reconcile(ctx context.Context, store sentStore) error {
+	added := 0
+	loadState := func(msg *dps.Message) error {
+		// Skip messages that are not web archive messages
+		if !o.Naming.IsOwnedSubmission(msg) {
+			return nil
+		}
+		// Skip messages that are not from the root directory
+		if !strings.HasPrefix(msg.Path, o.Dir) {
+			return nil
+		}
+		// Skip messages that are excluded explicitly
+		for _, re := range o.Exclude {
+			if re.MatchString(msg.Path) {
+				return nil
 			}
 		}
-		time.Sleep(1 * time.Minute)
+		sent, err := store.Has(msg.Path)
+		if err != nil {
+			return err
+		}
+		if sent {
+			return nil
+		}
+		added++
+		return store.Put(state.NewRecord(*msg))
+	}
+
+	slog.Info("Reconciling state file with kafka topic", "topic", o.KafkaTopic)
+	err := dps.ReadLatestMessages(ctx, o.Transport, o.KafkaTopic, loadState)
+	if err != nil {
+		return fmt.Errorf("failed to read latest messages: %w", err)
 	}
+	slog.Info("Reconciled state file with kafka topic", "topic", o.KafkaTopic, "added", added)
+	return nil
 }
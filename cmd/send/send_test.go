@@ -0,0 +1,161 @@
+package send
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/fixity"
+	"github.com/nlnwa/hermetic/internal/readiness"
+	"github.com/nlnwa/hermetic/internal/state"
+)
+
+// createWarcDirectory creates a valid WARC directory named name in root, with
+// the extra files that are not listed in the checksum file.
+func createWarcDirectory(t *testing.T, root string, name string, extra ...string) string {
+	t.Helper()
+	directory := filepath.Join(root, name)
+	if err := os.Mkdir(directory, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	warcName := name + ".warc.gz"
+	content := []byte("warc")
+	digest := md5.Sum(content)
+	files := map[string][]byte{
+		warcName:                content,
+		fixity.ChecksumFileName: []byte(hex.EncodeToString(digest[:]) + "  " + warcName + "\n"),
+	}
+	for _, file := range extra {
+		files[file] = nil
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, file), content, 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	return directory
+}
+
+func newTestOptions(t *testing.T) SendOptions {
+	t.Helper()
+	directory, err := os.MkdirTemp("", "send")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(directory) })
+
+	root := filepath.Join(directory, "root")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	return SendOptions{
+		KafkaTopic:        "transfer",
+		Transport:         dps.NewMemoryTransport(),
+		Dir:               root,
+		StateFile:         filepath.Join(directory, "state.db"),
+		VerifyFixity:      true,
+		Validate:          true,
+		Readiness:         readiness.Policy{RequiredFiles: []string{readiness.DirPlaceholder + ".warc.gz", fixity.ChecksumFileName}},
+		Once:              true,
+		UnexpectedEntries: entryPolicyWarn,
+		KeyStrategy:       dps.KeyRandom,
+		Naming:            dps.DefaultNamingScheme,
+	}
+}
+
+func TestSendWithMarkerFile(t *testing.T) {
+	o := newTestOptions(t)
+	o.Readiness.MarkerFile = ".complete"
+
+	complete := createWarcDirectory(t, o.Dir, "crawl-0001", ".complete")
+	createWarcDirectory(t, o.Dir, "crawl-0002")
+
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	records := o.Transport.(*dps.MemoryTransport).Records(o.KafkaTopic)
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(records))
+	}
+	var msg dps.Message
+	if err := json.Unmarshal(records[0].Value, &msg); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if msg.Path != complete {
+		t.Errorf("Expected path '%s', got '%s'", complete, msg.Path)
+	}
+}
+
+func TestSendOnce(t *testing.T) {
+	o := newTestOptions(t)
+	createWarcDirectory(t, o.Dir, "crawl-0001")
+	createWarcDirectory(t, o.Dir, "crawl-0002")
+
+	for range 2 {
+		if err := o.Run(); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	// Sent directories are recorded in the state file and not sent again
+	records := o.Transport.(*dps.MemoryTransport).Records(o.KafkaTopic)
+	if len(records) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(records))
+	}
+}
+
+func TestSendDryRun(t *testing.T) {
+	o := newTestOptions(t)
+	sent := createWarcDirectory(t, o.Dir, "crawl-0001")
+	unsent := createWarcDirectory(t, o.Dir, "crawl-0002")
+
+	store, err := state.Open(o.StateFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := store.Put(state.Record{Path: sent, Identifier: "identifier"}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var out bytes.Buffer
+	o.Once = false
+	o.DryRun = true
+	o.Output = &out
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if records := o.Transport.(*dps.MemoryTransport).Records(o.KafkaTopic); len(records) != 0 {
+		t.Errorf("Expected no messages to be sent, got %d", len(records))
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line of output, got %q", out.String())
+	}
+	var msg dps.Message
+	if err := json.Unmarshal([]byte(lines[0]), &msg); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if msg.Path != unsent {
+		t.Errorf("Expected path '%s', got '%s'", unsent, msg.Path)
+	}
+	store, err = state.Open(o.StateFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+	if ok, _ := store.Has(unsent); ok {
+		t.Errorf("Expected dry run not to record '%s' in the state file", unsent)
+	}
+}
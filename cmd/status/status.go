@@ -0,0 +1,256 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nlnwa/hermetic/cmd/internal/flags"
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/lifecycle"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	outputFlagName    string = "output"
+	outputHelpMessage string = "output format: 'table' or 'json'"
+
+	outputTable string = "table"
+	outputJson  string = "json"
+)
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().String(outputFlagName, outputTable, outputHelpMessage)
+	flags.AddResponseTopicFlags(cmd)
+}
+
+type StatusOptions struct {
+	Transport    dps.Transport
+	KafkaTopic   string
+	ConfirmTopic string
+	RejectTopic  string
+	Query        string
+	Format       string
+	Naming       dps.NamingScheme
+	Output       io.Writer
+}
+
+func toOptions(query string, output io.Writer) (StatusOptions, error) {
+	format := viper.GetString(outputFlagName)
+	if format != outputTable && format != outputJson {
+		return StatusOptions{}, fmt.Errorf("unknown output format '%s', expected '%s' or '%s'", format, outputTable, outputJson)
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return StatusOptions{}, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return StatusOptions{}, err
+	}
+
+	return StatusOptions{
+		Transport:    transport,
+		KafkaTopic:   flags.GetKafkaTopic(),
+		ConfirmTopic: flags.GetConfirmTopic(),
+		RejectTopic:  flags.GetRejectTopic(),
+		Query:        query,
+		Format:       format,
+		Naming:       naming,
+		Output:       output,
+	}, nil
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <path|urn|identifier>",
+		Short: "Shows the history of the submissions of a package",
+		Long: `Shows the history of the submissions of a package.
+
+The transfer topic (--kafka-topic) and the confirm and reject topics, if given,
+are replayed, and every submission whose identifier, URN or path equals the
+argument is printed with its state and the messages about it, including
+resubmissions and the checks of rejections.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			o, err := toOptions(args[0], cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+// Event is a message about a submission, as printed.
+type Event struct {
+	Kind      lifecycle.Kind `json:"kind"`
+	Topic     string         `json:"topic"`
+	Partition int            `json:"partition"`
+	Offset    int64          `json:"offset"`
+	Time      time.Time      `json:"time"`
+	Date      string         `json:"date"`
+	Checks    []dps.Check    `json:"checks,omitempty"`
+}
+
+// Status is a submission with every message about it.
+type Status struct {
+	lifecycle.Submission
+	Events []Event `json:"events"`
+}
+
+func (o StatusOptions) Run() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	statuses, err := o.collect(ctx)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		return fmt.Errorf("found no submission matching '%s'", o.Query)
+	}
+
+	if o.Format == outputJson {
+		encoder := json.NewEncoder(o.Output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+	return printTable(o.Output, statuses)
+}
+
+// collect replays the topics and returns the status of every matching submission.
+func (o StatusOptions) collect(ctx context.Context) ([]Status, error) {
+	topics := map[lifecycle.Kind]string{
+		lifecycle.KindTransfer: o.KafkaTopic,
+		lifecycle.KindConfirm:  o.ConfirmTopic,
+		lifecycle.KindReject:   o.RejectTopic,
+	}
+
+	var replayed []lifecycle.Event
+	// Resubmissions of each identifier
+	successors := make(map[string][]string)
+	// Identifiers of the submissions matching the query
+	var queue []string
+	err := lifecycle.ReplayTopics(ctx, o.Transport, topics, o.Naming.IsOwned, func(e lifecycle.Event) error {
+		e.Message.Files = nil
+		replayed = append(replayed, e)
+		if e.Kind == lifecycle.KindTransfer && e.Message.PreviousIdentifier != "" {
+			successors[e.Message.PreviousIdentifier] = append(successors[e.Message.PreviousIdentifier], e.Message.Identifier)
+		}
+		if o.matches(&e.Message) {
+			queue = append(queue, e.Message.Identifier)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Resubmissions of matching submissions match too, following the whole
+	// chain whatever order its messages were read in, e.g. from different partitions
+	matched := make(map[string]bool)
+	for len(queue) > 0 {
+		identifier := queue[0]
+		queue = queue[1:]
+		if matched[identifier] {
+			continue
+		}
+		matched[identifier] = true
+		queue = append(queue, successors[identifier]...)
+	}
+	var events []lifecycle.Event
+	for _, e := range replayed {
+		if matched[e.Message.Identifier] {
+			events = append(events, e)
+		}
+	}
+
+	byIdentifier := make(map[string][]Event)
+	for _, e := range events {
+		byIdentifier[e.Message.Identifier] = append(byIdentifier[e.Message.Identifier], Event{
+			Kind:      e.Kind,
+			Topic:     e.Topic,
+			Partition: e.Partition,
+			Offset:    e.Offset,
+			Time:      e.Time,
+			Date:      e.Message.Date,
+			Checks:    e.Message.Checks,
+		})
+	}
+
+	var statuses []Status
+	for _, submission := range lifecycle.Correlate(events) {
+		statuses = append(statuses, Status{Submission: submission, Events: byIdentifier[submission.Identifier]})
+	}
+	return statuses, nil
+}
+
+// matches reports whether the message is about the queried package, including
+// resubmissions of a queried identifier.
+func (o StatusOptions) matches(msg *dps.Message) bool {
+	return msg.Identifier == o.Query || msg.Urn == o.Query || msg.Path == o.Query || msg.PreviousIdentifier == o.Query
+}
+
+func printTable(w io.Writer, statuses []Status) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, s := range statuses {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "IDENTIFIER\t%s\n", s.Identifier)
+		fmt.Fprintf(tw, "STATE\t%s\n", s.State)
+		fmt.Fprintf(tw, "URN\t%s\n", s.Urn)
+		fmt.Fprintf(tw, "PATH\t%s\n", s.Path)
+		fmt.Fprintf(tw, "DATE\t%s\n", s.Date)
+		if s.PreviousIdentifier != "" {
+			fmt.Fprintf(tw, "PREVIOUS\t%s\n", s.PreviousIdentifier)
+		}
+		if s.ResubmittedAs != "" {
+			fmt.Fprintf(tw, "RESUBMITTED AS\t%s\n", s.ResubmittedAs)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "  KIND\tTOPIC\tPARTITION\tOFFSET\tTIME\tCHECKS")
+		for _, e := range s.Events {
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%d\t%s\t%s\n", e.Kind, e.Topic, e.Partition, e.Offset, e.Time.Format(time.RFC3339), summarize(e.Checks))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		for _, e := range s.Events {
+			for _, check := range e.Checks {
+				fmt.Fprintf(tw, "  %s check\t%s\t%s\t%s\t%s\n", e.Kind, check.Status, check.File, check.Message, check.Reason)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarize returns a short summary of checks.
+func summarize(checks []dps.Check) string {
+	if len(checks) == 0 {
+		return "-"
+	}
+	return dps.SummarizeChecks(dps.Message{Checks: checks}).String()
+}
@@ -0,0 +1,146 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/lifecycle"
+)
+
+func TestStatus(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := dps.NewMemoryTransport()
+	send := func(topic string, msg dps.Message) {
+		producer, _ := transport.Producer(topic, dps.KeyRandom)
+		value, _ := json.Marshal(msg)
+		if err := producer.WriteRecords(ctx, dps.Record{Value: value}); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	msg := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	resubmission, err := dps.CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	unrelated := dps.CreateMessage("/root/b", "b", dps.ContentTypeWarc)
+	rejection := msg
+	rejection.Checks = []dps.Check{{Status: "FAILED", Message: "checksum mismatch", File: "a.warc.gz"}}
+
+	send("transfer", msg)
+	send("transfer", unrelated)
+	send("transfer", resubmission)
+	send("reject", rejection)
+	send("confirm", resubmission)
+
+	var out bytes.Buffer
+	o := StatusOptions{
+		Transport:    transport,
+		KafkaTopic:   "transfer",
+		ConfirmTopic: "confirm",
+		RejectTopic:  "reject",
+		Query:        msg.Identifier,
+		Format:       outputJson,
+		Naming:       dps.DefaultNamingScheme,
+		Output:       &out,
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var statuses []Status
+	if err := json.Unmarshal(out.Bytes(), &statuses); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 submissions, got %d", len(statuses))
+	}
+	first := statuses[0]
+	if first.Identifier != msg.Identifier || first.State != lifecycle.Resubmitted || first.ResubmittedAs != resubmission.Identifier {
+		t.Errorf("Unexpected status of first submission %+v", first.Submission)
+	}
+	if len(first.Events) != 2 || first.Events[1].Kind != lifecycle.KindReject || len(first.Events[1].Checks) != 1 {
+		t.Errorf("Expected transfer and reject events, got %+v", first.Events)
+	}
+	if statuses[1].State != lifecycle.Confirmed {
+		t.Errorf("Expected %s, got %s", lifecycle.Confirmed, statuses[1].State)
+	}
+
+	out.Reset()
+	o.Query = msg.Path
+	o.Format = outputTable
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for _, want := range []string{msg.Identifier, resubmission.Identifier, "checksum mismatch", "resubmitted", "confirmed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected table to contain '%s', got\n%s", want, out.String())
+		}
+	}
+
+	o.Query = "/root/missing"
+	if err := o.Run(); err == nil {
+		t.Errorf("Expected error for unknown package, got nil")
+	}
+}
+
+func TestStatusResubmissionChain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := dps.NewMemoryTransport()
+	producer, _ := transport.Producer("transfer", dps.KeyRandom)
+
+	first := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	second, err := dps.CreateResubmission(first)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	third, err := dps.CreateResubmission(second)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	first.Date = "2024-01-01T00:00:00.000"
+	second.Date = "2024-02-01T00:00:00.000"
+	third.Date = "2024-03-01T00:00:00.000"
+	// Resubmissions are read before what they replace, as if from other partitions
+	for _, msg := range []dps.Message{third, second, first} {
+		if err := dps.Send(ctx, producer, msg, dps.KeyRandom); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	var out bytes.Buffer
+	o := StatusOptions{
+		Transport:  transport,
+		KafkaTopic: "transfer",
+		Query:      first.Identifier,
+		Format:     outputJson,
+		Naming:     dps.DefaultNamingScheme,
+		Output:     &out,
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var statuses []Status
+	if err := json.Unmarshal(out.Bytes(), &statuses); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("Expected the whole chain of 3 submissions, got %d", len(statuses))
+	}
+	if head := statuses[0]; head.Identifier != first.Identifier || head.ResubmittedAs != second.Identifier {
+		t.Errorf("Expected %s to be resubmitted as %s, got %+v", first.Identifier, second.Identifier, head.Submission)
+	}
+	if last := statuses[2]; last.Identifier != third.Identifier || last.State != lifecycle.Submitted {
+		t.Errorf("Expected %s to be %s, got %+v", third.Identifier, lifecycle.Submitted, last.Submission)
+	}
+}
@@ -0,0 +1,224 @@
+package track
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nlnwa/hermetic/cmd/internal/cmdutil"
+	"github.com/nlnwa/hermetic/cmd/internal/flags"
+	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/lifecycle"
+	"github.com/nlnwa/hermetic/internal/teams"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	timeoutFlagName       string = "timeout"
+	timeoutHelpMessage    string = "time a submission may await a response from DPS (the SLA) before it is timed out and reported as overdue, 0 to never time out"
+	checkIntervalFlagName string = "check-interval"
+	checkIntervalHelp     string = "how often to look for submissions that have timed out"
+	digestIntervalName    string = "digest-interval"
+	digestIntervalHelp    string = "how often to send a digest of all overdue submissions to teams, 0 to never send a digest"
+	onceFlagName          string = "once"
+	onceHelpMessage       string = "replay the topics up to their current end, update the lifecycle file and exit"
+	groupIDFlagName       string = "track-consumer-group-id"
+	groupIDHelpMessage    string = "consumer group ID for reading the topics, must differ from the group of verify and send"
+)
+
+func addFlags(cmd *cobra.Command) {
+	flags.AddLifecycleFileFlag(cmd)
+	cmd.Flags().Duration(timeoutFlagName, 0, timeoutHelpMessage)
+	cmd.Flags().Duration(checkIntervalFlagName, time.Minute, checkIntervalHelp)
+	cmd.Flags().Duration(digestIntervalName, 24*time.Hour, digestIntervalHelp)
+	cmd.Flags().Bool(onceFlagName, false, onceHelpMessage)
+	flags.AddResponseTopicFlags(cmd)
+	cmd.Flags().String(groupIDFlagName, "", groupIDHelpMessage)
+	flags.AddDeadLetterFlags(cmd)
+}
+
+type TrackOptions struct {
+	Transport            dps.Transport
+	KafkaTopic           string
+	ConfirmTopic         string
+	RejectTopic          string
+	KafkaConsumerGroupID string
+	LifecycleFile        string
+	Timeout              time.Duration
+	CheckInterval        time.Duration
+	DigestInterval       time.Duration
+	TeamsWebhookUrl      string
+	Once                 bool
+	Naming               dps.NamingScheme
+	DeadLetters          *dps.DeadLetters
+}
+
+func toOptions() (TrackOptions, error) {
+	if flags.GetConfirmTopic() == "" || flags.GetRejectTopic() == "" {
+		return TrackOptions{}, errors.New("confirm and reject topics are required")
+	}
+	if viper.GetDuration(timeoutFlagName) < 0 {
+		return TrackOptions{}, fmt.Errorf("--%s must not be negative", timeoutFlagName)
+	}
+	if viper.GetDuration(digestIntervalName) < 0 {
+		return TrackOptions{}, fmt.Errorf("--%s must not be negative", digestIntervalName)
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return TrackOptions{}, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return TrackOptions{}, err
+	}
+	deadLetters, err := flags.GetDeadLetters(transport)
+	if err != nil {
+		return TrackOptions{}, err
+	}
+
+	return TrackOptions{
+		Transport:            transport,
+		KafkaTopic:           flags.GetKafkaTopic(),
+		ConfirmTopic:         flags.GetConfirmTopic(),
+		RejectTopic:          flags.GetRejectTopic(),
+		KafkaConsumerGroupID: viper.GetString(groupIDFlagName),
+		LifecycleFile:        flags.GetLifecycleFile(),
+		Timeout:              viper.GetDuration(timeoutFlagName),
+		CheckInterval:        viper.GetDuration(checkIntervalFlagName),
+		DigestInterval:       viper.GetDuration(digestIntervalName),
+		TeamsWebhookUrl:      flags.GetTeamsWebhookNotificationUrl(),
+		Once:                 viper.GetBool(onceFlagName),
+		Naming:               naming,
+		DeadLetters:          deadLetters,
+	}, nil
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "track",
+		Short: "Tracks the lifecycle of every submission",
+		Long: `Tracks the lifecycle of every submission by correlating the transfer topic
+(--kafka-topic) with the confirm and reject topics on identifier, and records
+the state of each submission in the lifecycle file:
+
+  submitted → confirmed | rejected | resubmitted | timed-out
+
+A rejected or timed out submission becomes resubmitted when it is replaced
+by 'hermetic resubmit'.
+
+A submission that times out is overdue. When a teams webhook is configured,
+each check that finds overdue submissions sends an alert, and a digest of all
+submissions still overdue is sent every --digest-interval.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			o, err := toOptions()
+			if err != nil {
+				return err
+			}
+			return cmdutil.HandleError(o.Run())
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+func (o TrackOptions) Run() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	store, err := lifecycle.Open(o.LifecycleFile)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if o.DeadLetters != nil {
+		defer o.DeadLetters.Close()
+	}
+
+	tracker := &lifecycle.Tracker{
+		Store:     store,
+		Transport: o.Transport,
+		Topics: map[lifecycle.Kind]string{
+			lifecycle.KindTransfer: o.KafkaTopic,
+			lifecycle.KindConfirm:  o.ConfirmTopic,
+			lifecycle.KindReject:   o.RejectTopic,
+		},
+		GroupID:     o.KafkaConsumerGroupID,
+		Filter:      o.Naming.IsOwned,
+		DeadLetters: o.DeadLetters,
+		Timeout:     o.Timeout,
+		OnChange: func(s lifecycle.Submission) {
+			slog.Info("Submission changed state", "identifier", s.Identifier, "state", s.State, "path", s.Path, "urn", s.Urn)
+		},
+	}
+	if o.TeamsWebhookUrl != "" {
+		tracker.OnExpire = o.notifyOverdue
+	}
+
+	if o.Once {
+		if err := tracker.Replay(ctx); err != nil {
+			return err
+		}
+		slog.Info("Replayed topics", "lifecycleFile", o.LifecycleFile)
+		return nil
+	}
+
+	if o.TeamsWebhookUrl != "" && o.Timeout > 0 && o.DigestInterval > 0 {
+		// Stop sending digests before the store is closed
+		digestCtx, stopDigests := context.WithCancel(ctx)
+		defer stopDigests()
+		go o.sendDigests(digestCtx, store)
+	}
+
+	err = tracker.Run(ctx, o.CheckInterval)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// notifyOverdue alerts about submissions that just timed out. Several at once,
+// as when catching up on old submissions, are sent as a single digest.
+func (o TrackOptions) notifyOverdue(expired []lifecycle.Submission) {
+	now := time.Now()
+	if len(expired) == 1 {
+		cmdutil.Notify(teams.Overdue(expired[0], o.Timeout, now))
+		return
+	}
+	cmdutil.Notify(teams.OverdueDigest(expired, o.Timeout, now))
+}
+
+// sendDigests sends a digest of all overdue submissions every digest interval
+// until ctx is cancelled. Nothing is sent while no submission is overdue.
+func (o TrackOptions) sendDigests(ctx context.Context, store *lifecycle.Store) {
+	ticker := time.NewTicker(o.DigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			overdue, err := store.Overdue()
+			if err != nil {
+				slog.Error("Failed to list overdue submissions", "error", err)
+				continue
+			}
+			if len(overdue) == 0 {
+				continue
+			}
+			slog.Warn("Submissions are overdue", "count", len(overdue))
+			cmdutil.Notify(teams.OverdueDigest(overdue, o.Timeout, now))
+		}
+	}
+}
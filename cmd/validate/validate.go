@@ -0,0 +1,15 @@
+package validate
+
+import (
+	"github.com/nlnwa/hermetic/cmd/validate/warcdir"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	rootCommand := &cobra.Command{
+		Use:   "validate",
+		Short: "Validates data before it is sent to digital storage",
+	}
+	rootCommand.AddCommand(warcdir.NewCommand())
+	return rootCommand
+}
@@ -0,0 +1,104 @@
+package warcdir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nlnwa/hermetic/cmd/internal/flags"
+	"github.com/nlnwa/hermetic/internal/fixity"
+	"github.com/nlnwa/hermetic/internal/validate"
+	"github.com/nlnwa/hermetic/internal/warc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	verifyFixityFlagName    string = "verify-fixity"
+	verifyFixityHelpMessage string = "also verify the digests in checksum_transferred.md5"
+
+	verifyWarcFlagName    string = "verify-warc"
+	verifyWarcHelpMessage string = "also verify that every gzip member and WARC record header of the WARC file is intact"
+)
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(verifyFixityFlagName, false, verifyFixityHelpMessage)
+	cmd.Flags().Bool(verifyWarcFlagName, false, verifyWarcHelpMessage)
+	flags.AddMarkerFileFlag(cmd)
+}
+
+type WarcDirOptions struct {
+	Dir          string
+	VerifyFixity bool
+	VerifyWarc   bool
+	MarkerFile   string
+	Output       io.Writer
+}
+
+func toOptions(dir string, output io.Writer) WarcDirOptions {
+	return WarcDirOptions{
+		Dir:          dir,
+		VerifyFixity: viper.GetBool(verifyFixityFlagName),
+		VerifyWarc:   viper.GetBool(verifyWarcFlagName),
+		MarkerFile:   flags.GetMarkerFile(),
+		Output:       output,
+	}
+}
+
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "warc-dir <path>",
+		Short: "Validates the layout of a WARC payload directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return toOptions(args[0], cmd.OutOrStdout()).Run()
+		},
+	}
+
+	addFlags(cmd)
+
+	return cmd
+}
+
+func (o WarcDirOptions) Run() error {
+	// The marker file is not part of the payload, so it is allowed in addition to the payload files
+	var extraFiles []string
+	if o.MarkerFile != "" {
+		extraFiles = append(extraFiles, o.MarkerFile)
+	}
+
+	violations, err := validate.WarcDirectory(o.Dir, extraFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to validate '%s': %w", o.Dir, err)
+	}
+	for _, v := range violations {
+		fmt.Fprintln(o.Output, v)
+	}
+	if err := validate.Error(o.Dir, violations); err != nil {
+		return err
+	}
+
+	if o.VerifyFixity {
+		if err := fixity.VerifyDirectory(o.Dir, extraFiles...); err != nil {
+			return err
+		}
+	}
+
+	if o.VerifyWarc {
+		reports, err := warc.CheckDirectory(o.Dir)
+		if err != nil {
+			return fmt.Errorf("failed to check WARC files in '%s': %w", o.Dir, err)
+		}
+		var errs []error
+		for _, report := range reports {
+			fmt.Fprintf(o.Output, "%s: %d gzip members, %d records\n", report.Path, report.Members, report.Records)
+			errs = append(errs, report.Err())
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(o.Output, "%s: ok\n", o.Dir)
+	return nil
+}
@@ -0,0 +1,52 @@
+package warcdir
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlnwa/hermetic/internal/fixity"
+)
+
+func TestRunWithMarkerFile(t *testing.T) {
+	directory, err := os.MkdirTemp("", "warcdir")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "crawl-0001")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	content := []byte("warc")
+	digest := md5.Sum(content)
+	files := map[string][]byte{
+		"crawl-0001.warc.gz":    content,
+		fixity.ChecksumFileName: []byte(hex.EncodeToString(digest[:]) + "  crawl-0001.warc.gz\n"),
+		".complete":             nil,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(path, name), content, 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	var out bytes.Buffer
+	o := WarcDirOptions{Dir: path, VerifyFixity: true, Output: &out}
+	if err := o.Run(); err == nil {
+		t.Errorf("Expected unexpected file error, got nil")
+	}
+
+	out.Reset()
+	o.MarkerFile = ".complete"
+	if err := o.Run(); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if out.String() != path+": ok\n" {
+		t.Errorf("Expected ok, got '%s'", out.String())
+	}
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/nlnwa/hermetic/cmd/internal/cmdutil"
 	"github.com/nlnwa/hermetic/cmd/internal/flags"
 	"github.com/nlnwa/hermetic/internal/dps"
-	"github.com/segmentio/kafka-go"
+	"github.com/nlnwa/hermetic/internal/retry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -29,22 +29,51 @@ func addFlags(cmd *cobra.Command) {
 	cmd.Flags().String(receiverUrlFlagName, "", receiverUrlFlagHelpMessage)
 
 	flags.AddKafkaFlags(cmd)
+	flags.AddDeadLetterFlags(cmd)
+	flags.AddRetryFlags(cmd)
 }
 
-func toOptions() *ConfirmOptions {
+func toOptions() (*ConfirmOptions, error) {
+	groupID, err := flags.GetRequiredKafkaConsumerGroupID()
+	if err != nil {
+		return nil, err
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return nil, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return nil, err
+	}
+	retryPolicy, err := flags.GetRetryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := flags.GetDeadLetters(transport)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ConfirmOptions{
 		KafkaTopic:           flags.GetKafkaTopic(),
-		KafkaEndpoints:       flags.GetKafkaEndpoints(),
-		KafkaConsumerGroupID: flags.GetKafkaConsumerGroupID(),
+		Transport:            transport,
+		KafkaConsumerGroupID: groupID,
 		ReceiverUrl:          viper.GetString(receiverUrlFlagName),
-	}
+		Naming:               naming,
+		DeadLetters:          deadLetters,
+		Retry:                retryPolicy,
+	}, nil
 }
 
 type ConfirmOptions struct {
 	KafkaTopic           string
-	KafkaEndpoints       []string
+	Transport            dps.Transport
 	KafkaConsumerGroupID string
 	ReceiverUrl          string
+	Naming               dps.NamingScheme
+	DeadLetters          *dps.DeadLetters
+	Retry                retry.Policy
 }
 
 func NewCommand() *cobra.Command {
@@ -53,7 +82,14 @@ func NewCommand() *cobra.Command {
 		Short: "Continuously report all successfully preserved data",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return cmdutil.HandleError(toOptions().Run())
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			o, err := toOptions()
+			if err != nil {
+				return err
+			}
+			return cmdutil.HandleError(o.Run())
 		},
 	}
 
@@ -66,27 +102,36 @@ func (o *ConfirmOptions) Run() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: o.KafkaEndpoints,
-		Topic:   o.KafkaTopic,
-		GroupID: o.KafkaConsumerGroupID,
-	})
+	consumer, err := o.Transport.Consumer(o.KafkaTopic, o.KafkaConsumerGroupID)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	if o.DeadLetters != nil {
+		defer o.DeadLetters.Close()
+	}
 
 	for {
-		message, err := dps.NextMessage(ctx, reader, dps.IsWebArchiveOwned)
+		message, err := dps.NextMessage(ctx, consumer, o.Naming.IsOwned, o.DeadLetters)
 		if err != nil {
 			return fmt.Errorf("failed to read next message from kafka: %w", err)
 		}
 
-		slog.Info("Received confirm message from DPS", "message", message.Value, "key", message.Key, "offset", message.Offset)
+		slog.Info("Received confirm message from DPS", "message", message.Value, "key", message.Key, "headers", message.Headers, "offset", message.Offset)
 
-		if len(o.ReceiverUrl) == 0 {
-			continue
+		if len(o.ReceiverUrl) > 0 {
+			err = retry.Do(ctx, o.Retry, func(ctx context.Context) error {
+				return sendConfirmMessage(ctx, o.ReceiverUrl, message.Value)
+			}, cmdutil.LogRetry("Failed to send confirm message", "identifier", message.Value.Identifier, "offset", message.Offset))
+			if err != nil {
+				return fmt.Errorf("failed to send confirm message: %w", err)
+			}
 		}
 
-		err = sendConfirmMessage(ctx, o.ReceiverUrl, message.Value)
-		if err != nil {
-			return fmt.Errorf("failed to send confirm message: %w", err)
+		// Commit only after the confirmation has been delivered, so it is redelivered after a crash
+		if err := consumer.CommitRecords(ctx, message.Record); err != nil {
+			return fmt.Errorf("failed to commit message at offset %d: %w", message.Offset, err)
 		}
 	}
 }
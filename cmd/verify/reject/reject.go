@@ -10,13 +10,15 @@ import (
 	"github.com/nlnwa/hermetic/cmd/internal/cmdutil"
 	"github.com/nlnwa/hermetic/cmd/internal/flags"
 	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/retry"
 	"github.com/nlnwa/hermetic/internal/teams"
-	"github.com/segmentio/kafka-go"
 	"github.com/spf13/cobra"
 )
 
 func addFlags(cmd *cobra.Command) {
 	flags.AddKafkaFlags(cmd)
+	flags.AddDeadLetterFlags(cmd)
+	flags.AddRetryFlags(cmd)
 }
 
 type RejectOptions struct {
@@ -24,15 +26,44 @@ type RejectOptions struct {
 	KafkaTopic                  string
 	KafkaConsumerGroupID        string
 	TeamsWebhookNotificationUrl string
+	Naming                      dps.NamingScheme
+	Transport                   dps.Transport
+	DeadLetters                 *dps.DeadLetters
+	Retry                       retry.Policy
 }
 
-func toOptions() RejectOptions {
+func toOptions() (RejectOptions, error) {
+	groupID, err := flags.GetRequiredKafkaConsumerGroupID()
+	if err != nil {
+		return RejectOptions{}, err
+	}
+	naming, err := flags.GetNamingScheme()
+	if err != nil {
+		return RejectOptions{}, err
+	}
+	transport, err := flags.GetTransport()
+	if err != nil {
+		return RejectOptions{}, err
+	}
+	retryPolicy, err := flags.GetRetryPolicy()
+	if err != nil {
+		return RejectOptions{}, err
+	}
+	deadLetters, err := flags.GetDeadLetters(transport)
+	if err != nil {
+		return RejectOptions{}, err
+	}
+
 	return RejectOptions{
 		KafkaEndpoints:              flags.GetKafkaEndpoints(),
 		KafkaTopic:                  flags.GetKafkaTopic(),
-		KafkaConsumerGroupID:        flags.GetKafkaConsumerGroupID(),
+		KafkaConsumerGroupID:        groupID,
 		TeamsWebhookNotificationUrl: flags.GetTeamsWebhookNotificationUrl(),
-	}
+		Naming:                      naming,
+		Transport:                   transport,
+		DeadLetters:                 deadLetters,
+		Retry:                       retryPolicy,
+	}, nil
 }
 
 func NewCommand() *cobra.Command {
@@ -41,7 +72,14 @@ func NewCommand() *cobra.Command {
 		Short: "Continuously report all rejected data",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return cmdutil.HandleError(toOptions().Run())
+			if err := flags.ValidateGlobalFlags(); err != nil {
+				return err
+			}
+			o, err := toOptions()
+			if err != nil {
+				return err
+			}
+			return cmdutil.HandleError(o.Run())
 		},
 	}
 
@@ -54,27 +92,37 @@ func (o RejectOptions) Run() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: o.KafkaEndpoints,
-		Topic:   o.KafkaTopic,
-		GroupID: o.KafkaConsumerGroupID,
-	})
+	consumer, err := o.Transport.Consumer(o.KafkaTopic, o.KafkaConsumerGroupID)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	if o.DeadLetters != nil {
+		defer o.DeadLetters.Close()
+	}
 
 	for {
-		message, err := dps.NextMessage(ctx, reader, dps.IsWebArchiveOwned)
+		message, err := dps.NextMessage(ctx, consumer, o.Naming.IsOwned, o.DeadLetters)
 		if err != nil {
 			return fmt.Errorf("failed to read next message from kafka: %w", err)
 		}
 
-		slog.Info("Received reject message from DPS", "message", message.Value, "key", message.Key, "offset", message.Offset)
+		slog.Info("Received reject message from DPS", "message", message.Value, "key", message.Key, "headers", message.Headers, "offset", message.Offset, "summary", dps.SummarizeChecks(message.Value).String())
 
-		if len(o.TeamsWebhookNotificationUrl) == 0 {
-			continue
+		if len(o.TeamsWebhookNotificationUrl) > 0 {
+			teamsMsg := teams.VerificationError(message, o.KafkaTopic, o.KafkaEndpoints)
+			err = retry.Do(ctx, o.Retry, func(ctx context.Context) error {
+				return teams.SendMessage(ctx, teamsMsg, o.TeamsWebhookNotificationUrl)
+			}, cmdutil.LogRetry("Failed to send message to teams", "identifier", message.Value.Identifier, "offset", message.Offset))
+			if err != nil {
+				return fmt.Errorf("failed to send reject message to teams: %w", err)
+			}
 		}
 
-		teamsMsg := teams.VerificationError(message, o.KafkaTopic, o.KafkaEndpoints)
-		if err := teams.SendMessage(ctx, teamsMsg, o.TeamsWebhookNotificationUrl); err != nil {
-			slog.Error("Failed to send message to teams", "error", err)
+		// Commit only after the rejection has been reported, so it is redelivered after a crash
+		if err := consumer.CommitRecords(ctx, message.Record); err != nil {
+			return fmt.Errorf("failed to commit message at offset %d: %w", message.Offset, err)
 		}
 	}
 }
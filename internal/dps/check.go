@@ -0,0 +1,174 @@
+package dps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CheckStatus is the normalized outcome of a check performed by DPS.
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "passed"
+	CheckWarning CheckStatus = "warning"
+	CheckFailed  CheckStatus = "failed"
+	CheckUnknown CheckStatus = "unknown"
+)
+
+// ParseCheckStatus normalizes a status reported by DPS.
+func ParseCheckStatus(s string) CheckStatus {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "ok", "pass", "passed", "success", "successful", "valid":
+		return CheckPassed
+	case "warn", "warning":
+		return CheckWarning
+	case "fail", "failed", "failure", "error", "invalid", "rejected":
+		return CheckFailed
+	default:
+		return CheckUnknown
+	}
+}
+
+// CheckCategory classifies what a check found.
+type CheckCategory string
+
+const (
+	CategoryChecksumMismatch CheckCategory = "checksum-mismatch"
+	CategoryFormatInvalid    CheckCategory = "format-invalid"
+	CategoryMissingFile      CheckCategory = "missing-file"
+	CategoryVirus            CheckCategory = "virus"
+	CategoryPolicy           CheckCategory = "policy"
+	CategoryOther            CheckCategory = "other"
+)
+
+// categoryKeywords are matched in order as whole words against the reason and
+// message of a check. Missing files are matched before checksums, as the
+// reason for a missing file often names a checksum file.
+var categoryKeywords = []struct {
+	category CheckCategory
+	keywords []string
+}{
+	{CategoryVirus, []string{"virus", "malware", "infected"}},
+	{CategoryMissingFile, []string{"missing", "not found", "no such file", "does not exist"}},
+	{CategoryChecksumMismatch, []string{"checksum", "checksums", "md5", "sha1", "sha256", "digest", "digests", "fixity", "hash", "hashes"}},
+	{CategoryPolicy, []string{"policy", "not allowed", "forbidden", "permission", "permissions", "rights", "limit", "limits", "quota"}},
+	{CategoryFormatInvalid, []string{"format", "invalid", "malformed", "corrupt", "corrupted", "parse", "parsing", "validation"}},
+}
+
+// Severity orders how serious the outcome of a check is.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+func (c Check) CheckStatus() CheckStatus {
+	return ParseCheckStatus(c.Status)
+}
+
+// Category classifies the check from its reason and message.
+func (c Check) Category() CheckCategory {
+	// Words are separated by single spaces and the text is padded, so that
+	// keywords only match whole words
+	words := strings.FieldsFunc(strings.ToLower(c.Reason+" "+c.Message), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	text := " " + strings.Join(words, " ") + " "
+	for _, ck := range categoryKeywords {
+		for _, keyword := range ck.keywords {
+			if strings.Contains(text, " "+keyword+" ") {
+				return ck.category
+			}
+		}
+	}
+	return CategoryOther
+}
+
+func (c Check) Severity() Severity {
+	switch c.CheckStatus() {
+	case CheckPassed:
+		return SeverityInfo
+	case CheckFailed:
+		if c.Category() == CategoryVirus {
+			return SeverityCritical
+		}
+		return SeverityError
+	default:
+		return SeverityWarning
+	}
+}
+
+// CheckSummary summarizes the checks of a response from DPS.
+type CheckSummary struct {
+	Severity Severity
+	Passed   int
+	Warnings int
+	Failed   int
+	Unknown  int
+	// Categories counts checks that did not pass, by category.
+	Categories map[CheckCategory]int
+	// Files lists the files of checks that did not pass.
+	Files []string
+}
+
+// SummarizeChecks summarizes the checks of msg.
+func SummarizeChecks(msg Message) CheckSummary {
+	summary := CheckSummary{Categories: make(map[CheckCategory]int)}
+	files := make(map[string]bool)
+	for _, check := range msg.Checks {
+		if s := check.Severity(); s > summary.Severity {
+			summary.Severity = s
+		}
+		switch check.CheckStatus() {
+		case CheckPassed:
+			summary.Passed++
+			continue
+		case CheckWarning:
+			summary.Warnings++
+		case CheckFailed:
+			summary.Failed++
+		default:
+			summary.Unknown++
+		}
+		summary.Categories[check.Category()]++
+		if check.File != "" && !files[check.File] {
+			files[check.File] = true
+			summary.Files = append(summary.Files, check.File)
+		}
+	}
+	return summary
+}
+
+func (s CheckSummary) String() string {
+	categories := make([]string, 0, len(s.Categories))
+	for category := range s.Categories {
+		categories = append(categories, string(category))
+	}
+	sort.Strings(categories)
+
+	text := fmt.Sprintf("%s: %d failed, %d warnings, %d unknown, %d passed", s.Severity, s.Failed, s.Warnings, s.Unknown, s.Passed)
+	if len(categories) > 0 {
+		text += " (" + strings.Join(categories, ", ") + ")"
+	}
+	return text
+}
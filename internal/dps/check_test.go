@@ -0,0 +1,66 @@
+package dps
+
+import (
+	"testing"
+)
+
+func TestCheckClassification(t *testing.T) {
+	tests := []struct {
+		check    Check
+		status   CheckStatus
+		category CheckCategory
+		severity Severity
+	}{
+		{Check{Status: "OK"}, CheckPassed, CategoryOther, SeverityInfo},
+		{Check{Status: "FAILED", Reason: "MD5 checksum mismatch"}, CheckFailed, CategoryChecksumMismatch, SeverityError},
+		{Check{Status: "failed", Message: "Virus found in file"}, CheckFailed, CategoryVirus, SeverityCritical},
+		{Check{Status: "Error", Reason: "File not found"}, CheckFailed, CategoryMissingFile, SeverityError},
+		{Check{Status: "FAILED", Reason: "Invalid WARC format"}, CheckFailed, CategoryFormatInvalid, SeverityError},
+		{Check{Status: "WARNING", Reason: "Size limit exceeded"}, CheckWarning, CategoryPolicy, SeverityWarning},
+		{Check{Status: "FAILED", Reason: "checksum_transferred.md5 not found"}, CheckFailed, CategoryMissingFile, SeverityError},
+		{Check{Status: "FAILED", Reason: "Unlimited copyrights"}, CheckFailed, CategoryOther, SeverityError},
+		{Check{Status: "FAILED", Message: "sha256: digest mismatch"}, CheckFailed, CategoryChecksumMismatch, SeverityError},
+		{Check{Status: "status", Reason: "reason"}, CheckUnknown, CategoryOther, SeverityWarning},
+	}
+	for _, test := range tests {
+		if status := test.check.CheckStatus(); status != test.status {
+			t.Errorf("Expected status %s for %v, got %s", test.status, test.check, status)
+		}
+		if category := test.check.Category(); category != test.category {
+			t.Errorf("Expected category %s for %v, got %s", test.category, test.check, category)
+		}
+		if severity := test.check.Severity(); severity != test.severity {
+			t.Errorf("Expected severity %s for %v, got %s", test.severity, test.check, severity)
+		}
+	}
+}
+
+func TestSummarizeChecks(t *testing.T) {
+	msg := Message{
+		Checks: []Check{
+			{Status: "OK", File: "a.warc.gz"},
+			{Status: "FAILED", Reason: "checksum mismatch", File: "a.warc.gz"},
+			{Status: "FAILED", Reason: "missing file", File: "checksum_transferred.md5"},
+			{Status: "WARNING", Reason: "deprecated format", File: "a.warc.gz"},
+		},
+	}
+
+	summary := SummarizeChecks(msg)
+
+	if summary.Severity != SeverityError {
+		t.Errorf("Expected severity %s, got %s", SeverityError, summary.Severity)
+	}
+	if summary.Passed != 1 || summary.Failed != 2 || summary.Warnings != 1 || summary.Unknown != 0 {
+		t.Errorf("Unexpected counts in %+v", summary)
+	}
+	if summary.Categories[CategoryChecksumMismatch] != 1 || summary.Categories[CategoryMissingFile] != 1 || summary.Categories[CategoryFormatInvalid] != 1 {
+		t.Errorf("Unexpected categories %v", summary.Categories)
+	}
+	if len(summary.Files) != 2 {
+		t.Errorf("Expected 2 files, got %v", summary.Files)
+	}
+	expected := "error: 2 failed, 1 warnings, 0 unknown, 1 passed (checksum-mismatch, format-invalid, missing-file)"
+	if summary.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, summary.String())
+	}
+}
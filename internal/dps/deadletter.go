@@ -0,0 +1,147 @@
+package dps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Headers added to records forwarded to a dead-letter topic.
+const (
+	HeaderDeadLetterReason = "hermetic-dead-letter-reason"
+	HeaderDeadLetterTopic  = "hermetic-dead-letter-topic"
+	HeaderDeadLetterOffset = "hermetic-dead-letter-offset"
+)
+
+// DeadLetter is a record that could not be processed.
+type DeadLetter struct {
+	Record Record
+	Reason string
+	Time   time.Time
+}
+
+// DeadLetterSink receives dead letters.
+type DeadLetterSink interface {
+	WriteDeadLetter(ctx context.Context, letter DeadLetter) error
+	Close() error
+}
+
+// DeadLetters skips records that can not be processed, so a single malformed
+// record can not stop a consumer. Skipped records are logged, counted and
+// forwarded to Sink if it is not nil.
+type DeadLetters struct {
+	Sink  DeadLetterSink
+	count atomic.Int64
+}
+
+// Count returns the number of records skipped.
+func (d *DeadLetters) Count() int64 {
+	return d.count.Load()
+}
+
+// Handle forwards the record to the sink and counts it. The record must not be
+// skipped if forwarding fails.
+func (d *DeadLetters) Handle(ctx context.Context, record Record, reason error) error {
+	letter := DeadLetter{Record: record, Reason: reason.Error(), Time: time.Now().UTC()}
+	if d.Sink != nil {
+		if err := d.Sink.WriteDeadLetter(ctx, letter); err != nil {
+			return fmt.Errorf("failed to forward dead letter at offset %d: %w", record.Offset, err)
+		}
+	}
+	count := d.count.Add(1)
+	slog.Warn("Skipped message that could not be processed", "topic", record.Topic, "partition", record.Partition, "offset", record.Offset, "reason", letter.Reason, "forwarded", d.Sink != nil, "deadLetters", count)
+	return nil
+}
+
+// Close closes the sink, if any.
+func (d *DeadLetters) Close() error {
+	if d.Sink == nil {
+		return nil
+	}
+	return d.Sink.Close()
+}
+
+// DeadLetterTopic forwards dead letters unchanged to a topic, with headers
+// telling where they came from and why they were skipped.
+type DeadLetterTopic struct {
+	Producer Producer
+}
+
+func (s DeadLetterTopic) WriteDeadLetter(ctx context.Context, letter DeadLetter) error {
+	headers := make(map[string]string, len(letter.Record.Headers)+3)
+	for k, v := range letter.Record.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterReason] = letter.Reason
+	headers[HeaderDeadLetterTopic] = letter.Record.Topic
+	headers[HeaderDeadLetterOffset] = strconv.FormatInt(letter.Record.Offset, 10)
+
+	return s.Producer.WriteRecords(ctx, Record{
+		Key:     letter.Record.Key,
+		Headers: headers,
+		Value:   letter.Record.Value,
+	})
+}
+
+func (s DeadLetterTopic) Close() error {
+	return s.Producer.Close()
+}
+
+// DeadLetterFile appends dead letters to a file as JSON lines, with the raw
+// value base64 encoded.
+type DeadLetterFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+type deadLetterLine struct {
+	Time      time.Time         `json:"time"`
+	Topic     string            `json:"topic"`
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Reason    string            `json:"reason"`
+	Key       []byte            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Value     []byte            `json:"value"`
+}
+
+func OpenDeadLetterFile(path string) (*DeadLetterFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	return &DeadLetterFile{file: f}, nil
+}
+
+func (s *DeadLetterFile) WriteDeadLetter(_ context.Context, letter DeadLetter) error {
+	line, err := json.Marshal(deadLetterLine{
+		Time:      letter.Time,
+		Topic:     letter.Record.Topic,
+		Partition: letter.Record.Partition,
+		Offset:    letter.Record.Offset,
+		Reason:    letter.Reason,
+		Key:       letter.Record.Key,
+		Headers:   letter.Record.Headers,
+		Value:     letter.Record.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *DeadLetterFile) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,135 @@
+package dps
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePoison writes a malformed record, an invalid owned response and a valid
+// owned response to topic and returns the valid response.
+func writePoison(t *testing.T, ctx context.Context, transport Transport, topic string) Message {
+	producer, _ := transport.Producer(topic, KeyRandom)
+
+	invalid, _ := json.Marshal(Message{ContentCategory: DefaultNamingScheme.ContentCategory})
+	err := producer.WriteRecords(ctx,
+		Record{Value: []byte("{not json")},
+		Record{Value: invalid, Headers: map[string]string{HeaderSchemaVersion: SchemaVersion}},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	valid := CreateMessage("/root/a", "a", ContentTypeWarc)
+	if err := Send(ctx, producer, valid, KeyRandom); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return valid
+}
+
+func TestNextMessageWithoutDeadLetters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewMemoryTransport()
+	writePoison(t, ctx, transport, "confirm")
+
+	consumer, _ := transport.Consumer("confirm", "group")
+	if _, err := NextMessage(ctx, consumer, IsWebArchiveOwned, nil); err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+
+	// The poison message is not committed, so it is read again
+	consumer, _ = transport.Consumer("confirm", "group")
+	if _, err := NextMessage(ctx, consumer, IsWebArchiveOwned, nil); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestNextMessageDeadLetterTopic(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewMemoryTransport()
+	valid := writePoison(t, ctx, transport, "confirm")
+
+	producer, _ := transport.Producer("dead-letters", KeyRandom)
+	deadLetters := &DeadLetters{Sink: DeadLetterTopic{Producer: producer}}
+	consumer, _ := transport.Consumer("confirm", "group")
+
+	message, err := NextMessage(ctx, consumer, IsWebArchiveOwned, deadLetters)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if message.Value.Identifier != valid.Identifier {
+		t.Errorf("Expected %s, got %s", valid.Identifier, message.Value.Identifier)
+	}
+	if deadLetters.Count() != 2 {
+		t.Errorf("Expected 2 dead letters, got %d", deadLetters.Count())
+	}
+
+	forwarded := transport.Records("dead-letters")
+	if len(forwarded) != 2 {
+		t.Fatalf("Expected 2 forwarded records, got %d", len(forwarded))
+	}
+	if string(forwarded[0].Value) != "{not json" {
+		t.Errorf("Expected raw value to be forwarded, got '%s'", forwarded[0].Value)
+	}
+	if forwarded[1].Headers[HeaderDeadLetterTopic] != "confirm" || forwarded[1].Headers[HeaderDeadLetterOffset] != "1" || forwarded[1].Headers[HeaderDeadLetterReason] == "" {
+		t.Errorf("Unexpected dead-letter headers %v", forwarded[1].Headers)
+	}
+}
+
+func TestNextMessageDeadLetterFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "dead-letters")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport := NewMemoryTransport()
+	writePoison(t, ctx, transport, "reject")
+
+	path := filepath.Join(dir, "dead-letters.jsonl")
+	sink, err := OpenDeadLetterFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	deadLetters := &DeadLetters{Sink: sink}
+	consumer, _ := transport.Consumer("reject", "")
+
+	if _, err := NextMessage(ctx, consumer, IsWebArchiveOwned, deadLetters); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := deadLetters.Close(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer f.Close()
+
+	var lines []deadLetterLine
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line deadLetterLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if string(lines[0].Value) != "{not json" || lines[0].Topic != "reject" || lines[0].Reason == "" {
+		t.Errorf("Unexpected dead letter %+v", lines[0])
+	}
+}
@@ -0,0 +1,49 @@
+package dps
+
+import (
+	"os"
+
+	"github.com/nlnwa/hermetic/internal/version"
+	"github.com/segmentio/kafka-go"
+)
+
+// SchemaVersion is the version of the message format written by hermetic.
+const SchemaVersion = "1"
+
+// Kafka record headers attached to sent messages.
+const (
+	HeaderContentType   = "content-type"
+	HeaderSchemaVersion = "hermetic-schema-version"
+	HeaderVersion       = "hermetic-version"
+	HeaderHost          = "hermetic-host"
+	// HeaderCorrelationID holds the identifier of the submission, so
+	// consumers can correlate records without parsing the value.
+	HeaderCorrelationID = "hermetic-correlation-id"
+)
+
+func createHeaders(msg Message) map[string]string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return map[string]string{
+		HeaderContentType:   "application/json",
+		HeaderSchemaVersion: SchemaVersion,
+		HeaderVersion:       version.Get(),
+		HeaderHost:          host,
+		HeaderCorrelationID: msg.Identifier,
+	}
+}
+
+// headersToMap returns the headers of a kafka record as a map. If a header
+// occurs more than once the last value wins.
+func headersToMap(headers []kafka.Header) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(headers))
+	for _, h := range headers {
+		m[h.Key] = string(h.Value)
+	}
+	return m
+}
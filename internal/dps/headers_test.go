@@ -0,0 +1,23 @@
+package dps
+
+import (
+	"testing"
+)
+
+func TestCreateHeaders(t *testing.T) {
+	msg := CreateMessage("/path/to/crawl-0001", "crawl-0001", ContentTypeWarc)
+
+	headers := headersToMap(toKafkaHeaders(createHeaders(msg)))
+
+	for _, key := range []string{HeaderContentType, HeaderSchemaVersion, HeaderVersion, HeaderHost, HeaderCorrelationID} {
+		if headers[key] == "" {
+			t.Errorf("Expected header '%s' to be set, got '%v'", key, headers)
+		}
+	}
+	if headers[HeaderCorrelationID] != msg.Identifier {
+		t.Errorf("Expected correlation id %s, got %s", msg.Identifier, headers[HeaderCorrelationID])
+	}
+	if headers[HeaderSchemaVersion] != SchemaVersion {
+		t.Errorf("Expected schema version %s, got %s", SchemaVersion, headers[HeaderSchemaVersion])
+	}
+}
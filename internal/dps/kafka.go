@@ -0,0 +1,361 @@
+package dps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaTransport carries messages over a kafka cluster.
+type KafkaTransport struct {
+	Endpoints []string
+
+	// dialer is used by readers and for connections to brokers
+	dialer *kafka.Dialer
+	// transport is used by writers
+	transport *kafka.Transport
+}
+
+// NewKafkaTransport returns a transport that encrypts and authenticates every
+// connection to the brokers as configured by auth.
+func NewKafkaTransport(endpoints []string, auth KafkaAuth) (*KafkaTransport, error) {
+	tlsConfig, err := auth.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := auth.Mechanism()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaTransport{
+		Endpoints: endpoints,
+		dialer: &kafka.Dialer{
+			Timeout:       10 * time.Second,
+			DualStack:     true,
+			TLS:           tlsConfig,
+			SASLMechanism: mechanism,
+		},
+		transport: &kafka.Transport{
+			TLS:  tlsConfig,
+			SASL: mechanism,
+		},
+	}, nil
+}
+
+func (t *KafkaTransport) Producer(topic string, keyStrategy KeyStrategy) (Producer, error) {
+	return &kafkaProducer{writer: &kafka.Writer{
+		Addr:      kafka.TCP(t.Endpoints...),
+		Topic:     topic,
+		Balancer:  keyStrategy.Balancer(),
+		Transport: t.transport,
+	}}, nil
+}
+
+// Consumer returns a consumer of topic. Without a consumer group, a kafka
+// reader only reads a single partition, so every partition gets a reader of its own.
+func (t *KafkaTransport) Consumer(topic string, groupID string) (Consumer, error) {
+	if groupID == "" {
+		partitions, err := t.partitions(topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get kafka partitions: %w", err)
+		}
+		readers := make([]messageFetcher, len(partitions))
+		for i, partition := range partitions {
+			readers[i] = kafka.NewReader(kafka.ReaderConfig{
+				Brokers:   t.Endpoints,
+				Topic:     topic,
+				Partition: partition.ID,
+				Dialer:    t.dialer,
+			})
+		}
+		return newPartitionsConsumer(readers), nil
+	}
+	return &kafkaConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: t.Endpoints,
+			Topic:   topic,
+			GroupID: groupID,
+			Dialer:  t.dialer,
+		}),
+	}, nil
+}
+
+// Replay reads the partitions of the topic concurrently, so records from
+// different partitions may be interleaved. The first error cancels reading of
+// the other partitions.
+func (t *KafkaTransport) Replay(ctx context.Context, topic string, fn func(Record) error) error {
+	partitions, err := t.partitions(topic)
+	if err != nil {
+		return fmt.Errorf("failed to get kafka partitions: %w", err)
+	}
+	return replayPartitions(ctx, partitions, t.replayPartition, fn)
+}
+
+// replayPartitions replays every partition concurrently, but never calls fn
+// concurrently. The first partition to fail cancels the others, and its error
+// is returned.
+func replayPartitions(ctx context.Context, partitions []kafka.Partition, replay func(context.Context, kafka.Partition, func(Record) error) error, fn func(Record) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	serialFn := func(record Record) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return fn(record)
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, partition := range partitions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := replay(ctx, partition, serialFn); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("partition %d: %w", partition.ID, err)
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func (t *KafkaTransport) partitions(topic string) ([]kafka.Partition, error) {
+	if len(t.Endpoints) == 0 {
+		return nil, errors.New("no kafka endpoints provided")
+	}
+
+	kafkaConn, err := t.dialer.Dial("tcp", t.Endpoints[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+	defer kafkaConn.Close()
+
+	partitions, err := kafkaConn.ReadPartitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("found no partitions of topic '%s'", topic)
+	}
+
+	return partitions, nil
+}
+
+func (t *KafkaTransport) partitionLeader(ctx context.Context, partition kafka.Partition) (*kafka.Conn, error) {
+	conn, err := t.dialer.DialPartition(ctx, "tcp", "", partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial tcp: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (t *KafkaTransport) replayPartition(ctx context.Context, partition kafka.Partition, fn func(Record) error) error {
+	conn, err := t.partitionLeader(ctx, partition)
+	if err != nil {
+		return fmt.Errorf("failed to get kafka partition leader: %w", err)
+	}
+	firstOffset, lastOffset, err := conn.ReadOffsets()
+	if err != nil {
+		return fmt.Errorf("failed to get first and last offset: %w", err)
+	}
+	if err := conn.Close(); err != nil {
+		return fmt.Errorf("failed to close connection to leader: %w", err)
+	}
+	if firstOffset >= lastOffset {
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   t.Endpoints,
+		Topic:     partition.Topic,
+		Partition: partition.ID,
+		Dialer:    t.dialer,
+	})
+	defer reader.Close()
+
+	err = reader.SetOffset(firstOffset)
+	if err != nil {
+		return fmt.Errorf("failed to set kafka reader offset '%d': %w", firstOffset, err)
+	}
+	for reader.Offset() < lastOffset {
+		kafkaMsg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(fromKafkaMessage(kafkaMsg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type kafkaProducer struct {
+	writer *kafka.Writer
+}
+
+func (p *kafkaProducer) WriteRecords(ctx context.Context, records ...Record) error {
+	msgs := make([]kafka.Message, len(records))
+	for i, record := range records {
+		msgs[i] = kafka.Message{
+			Key:     record.Key,
+			Value:   record.Value,
+			Headers: toKafkaHeaders(record.Headers),
+		}
+	}
+	return p.writer.WriteMessages(ctx, msgs...)
+}
+
+func (p *kafkaProducer) Close() error {
+	return p.writer.Close()
+}
+
+// kafkaConsumer consumes a topic as a member of a consumer group.
+type kafkaConsumer struct {
+	reader *kafka.Reader
+}
+
+func (c *kafkaConsumer) FetchRecord(ctx context.Context) (Record, error) {
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return Record{}, err
+	}
+	return fromKafkaMessage(msg), nil
+}
+
+// CommitRecords commits the offsets of records for the consumer group.
+func (c *kafkaConsumer) CommitRecords(ctx context.Context, records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	msgs := make([]kafka.Message, len(records))
+	for i, record := range records {
+		msgs[i] = kafka.Message{
+			Topic:     record.Topic,
+			Partition: record.Partition,
+			Offset:    record.Offset,
+		}
+	}
+	return c.reader.CommitMessages(ctx, msgs...)
+}
+
+func (c *kafkaConsumer) Close() error {
+	return c.reader.Close()
+}
+
+// messageFetcher is the part of a kafka reader used by consumers.
+type messageFetcher interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
+type fetchedMessage struct {
+	msg kafka.Message
+	err error
+}
+
+// partitionsConsumer consumes every partition of a topic without a consumer
+// group, with one reader per partition. Records of a partition are fetched in
+// order, but records of different partitions may be interleaved.
+type partitionsConsumer struct {
+	readers  []messageFetcher
+	messages chan fetchedMessage
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func newPartitionsConsumer(readers []messageFetcher) *partitionsConsumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &partitionsConsumer{
+		readers:  readers,
+		messages: make(chan fetchedMessage),
+		cancel:   cancel,
+	}
+	for _, reader := range readers {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for {
+				msg, err := reader.FetchMessage(ctx)
+				select {
+				case c.messages <- fetchedMessage{msg: msg, err: err}:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	return c
+}
+
+func (c *partitionsConsumer) FetchRecord(ctx context.Context) (Record, error) {
+	select {
+	case <-ctx.Done():
+		return Record{}, ctx.Err()
+	case fetched := <-c.messages:
+		if fetched.err != nil {
+			return Record{}, fetched.err
+		}
+		return fromKafkaMessage(fetched.msg), nil
+	}
+}
+
+// CommitRecords does nothing, as there is no consumer group to commit for.
+func (c *partitionsConsumer) CommitRecords(ctx context.Context, records ...Record) error {
+	return nil
+}
+
+func (c *partitionsConsumer) Close() error {
+	c.cancel()
+	var errs []error
+	for _, reader := range c.readers {
+		errs = append(errs, reader.Close())
+	}
+	c.wg.Wait()
+	return errors.Join(errs...)
+}
+
+func fromKafkaMessage(msg kafka.Message) Record {
+	return Record{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Time:      msg.Time,
+		Key:       msg.Key,
+		Headers:   headersToMap(msg.Headers),
+		Value:     msg.Value,
+	}
+}
+
+// toKafkaHeaders returns headers as kafka record headers, sorted by key.
+func toKafkaHeaders(headers map[string]string) []kafka.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kafkaHeaders := make([]kafka.Header, len(keys))
+	for i, key := range keys {
+		kafkaHeaders[i] = kafka.Header{Key: key, Value: []byte(headers[key])}
+	}
+	return kafkaHeaders
+}
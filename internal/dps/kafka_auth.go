@@ -0,0 +1,96 @@
+package dps
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms supported for kafka authentication.
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// KafkaAuth configures encryption and authentication of kafka connections.
+type KafkaAuth struct {
+	// TLS enables TLS. It is implied by any of the files below.
+	TLS bool
+	// CAFile is a PEM bundle of certificate authorities used to verify
+	// brokers, instead of the system pool.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and its key.
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+
+	// SASLMechanism is empty for no SASL, or one of "plain", "scram-sha-256" and "scram-sha-512".
+	SASLMechanism string
+	Username      string
+	Password      string
+}
+
+// TLSConfig returns the TLS configuration, or nil if TLS is disabled.
+func (a KafkaAuth) TLSConfig() (*tls.Config, error) {
+	if !a.TLS && a.CAFile == "" && a.CertFile == "" && a.KeyFile == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: a.InsecureSkipVerify,
+	}
+
+	if a.CAFile != "" {
+		pem, err := os.ReadFile(a.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("found no certificates in CA file '%s'", a.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if (a.CertFile == "") != (a.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be given together")
+	}
+	if a.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(a.CertFile, a.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// Mechanism returns the SASL mechanism, or nil if SASL is disabled.
+func (a KafkaAuth) Mechanism() (sasl.Mechanism, error) {
+	if a.SASLMechanism == "" {
+		return nil, nil
+	}
+	if a.Username == "" || a.Password == "" {
+		return nil, fmt.Errorf("SASL mechanism '%s' requires a username and a password", a.SASLMechanism)
+	}
+
+	switch a.SASLMechanism {
+	case SASLPlain:
+		return plain.Mechanism{Username: a.Username, Password: a.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, a.Username, a.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, a.Username, a.Password)
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism '%s', expected one of '%s', '%s' or '%s'", a.SASLMechanism, SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+}
@@ -0,0 +1,110 @@
+package dps
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile string, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "hermetic"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return certFile, keyFile
+}
+
+func TestKafkaAuthTLS(t *testing.T) {
+	dir, err := os.MkdirTemp("", "kafka-auth")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config, err := KafkaAuth{}.TLSConfig()
+	if err != nil || config != nil {
+		t.Errorf("Expected no TLS config, got %v, '%v'", config, err)
+	}
+
+	certFile, keyFile := writeCertificate(t, dir)
+	config, err = KafkaAuth{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}.TLSConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if config.RootCAs == nil {
+		t.Errorf("Expected root CAs to be set")
+	}
+	if len(config.Certificates) != 1 {
+		t.Errorf("Expected 1 client certificate, got %d", len(config.Certificates))
+	}
+
+	if _, err := (KafkaAuth{CertFile: certFile}).TLSConfig(); err == nil {
+		t.Errorf("Expected error for certificate without key, got nil")
+	}
+	if _, err := (KafkaAuth{CAFile: keyFile}).TLSConfig(); err == nil {
+		t.Errorf("Expected error for CA file without certificates, got nil")
+	}
+}
+
+func TestKafkaAuthMechanism(t *testing.T) {
+	mechanism, err := KafkaAuth{}.Mechanism()
+	if err != nil || mechanism != nil {
+		t.Errorf("Expected no mechanism, got %v, '%v'", mechanism, err)
+	}
+
+	expected := map[string]string{
+		SASLPlain:       "PLAIN",
+		SASLScramSHA256: "SCRAM-SHA-256",
+		SASLScramSHA512: "SCRAM-SHA-512",
+	}
+	for name, want := range expected {
+		mechanism, err := KafkaAuth{SASLMechanism: name, Username: "user", Password: "secret"}.Mechanism()
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if mechanism.Name() != want {
+			t.Errorf("Expected %s, got %s", want, mechanism.Name())
+		}
+	}
+
+	if _, err := (KafkaAuth{SASLMechanism: SASLPlain, Username: "user"}).Mechanism(); err == nil {
+		t.Errorf("Expected error without password, got nil")
+	}
+	if _, err := (KafkaAuth{SASLMechanism: "gssapi", Username: "user", Password: "secret"}).Mechanism(); err == nil {
+		t.Errorf("Expected error for unknown mechanism, got nil")
+	}
+}
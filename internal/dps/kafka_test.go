@@ -0,0 +1,183 @@
+package dps
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// replayRecords returns a replay of records per partition ID, yielding between records.
+func replayRecords(records map[int][]Record) func(context.Context, kafka.Partition, func(Record) error) error {
+	return func(ctx context.Context, partition kafka.Partition, fn func(Record) error) error {
+		for _, record := range records[partition.ID] {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}
+}
+
+func TestReplayPartitions(t *testing.T) {
+	partitions := []kafka.Partition{{Topic: "topic", ID: 0}, {Topic: "topic", ID: 1}, {Topic: "topic", ID: 2}}
+	records := make(map[int][]Record)
+	for _, partition := range partitions {
+		for offset := range 10 {
+			records[partition.ID] = append(records[partition.ID], Record{Topic: "topic", Partition: partition.ID, Offset: int64(offset)})
+		}
+	}
+
+	var active atomic.Int32
+	next := make(map[int]int64)
+	err := replayPartitions(context.Background(), partitions, replayRecords(records), func(record Record) error {
+		if active.Add(1) > 1 {
+			t.Errorf("Expected fn not to be called concurrently")
+		}
+		defer active.Add(-1)
+		if record.Offset != next[record.Partition] {
+			t.Errorf("Expected offset %d of partition %d, got %d", next[record.Partition], record.Partition, record.Offset)
+		}
+		next[record.Partition] = record.Offset + 1
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for _, partition := range partitions {
+		if next[partition.ID] != 10 {
+			t.Errorf("Expected 10 records of partition %d, got %d", partition.ID, next[partition.ID])
+		}
+	}
+}
+
+func TestReplayPartitionsError(t *testing.T) {
+	partitions := []kafka.Partition{{Topic: "topic", ID: 0}, {Topic: "topic", ID: 1}}
+	failure := errors.New("failure")
+
+	replay := func(ctx context.Context, partition kafka.Partition, fn func(Record) error) error {
+		if partition.ID == 1 {
+			return failure
+		}
+		// Partition 0 never ends unless cancelled
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := replayPartitions(ctx, partitions, replay, func(Record) error { return nil })
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected '%s', got '%v'", failure, err)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("Expected the other partition to be cancelled, got '%s'", ctx.Err())
+	}
+
+	// An error of fn stops the replay too
+	records := map[int][]Record{0: {{Partition: 0}}, 1: {{Partition: 1}}}
+	err = replayPartitions(ctx, partitions, replayRecords(records), func(record Record) error {
+		if record.Partition == 1 {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected '%s', got '%v'", failure, err)
+	}
+}
+
+// fakeReader returns its messages in order, then blocks until cancelled, or fails with err.
+type fakeReader struct {
+	messages []kafka.Message
+	err      error
+	closed   atomic.Bool
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		return msg, nil
+	}
+	if r.err != nil {
+		return kafka.Message{}, r.err
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+func TestPartitionsConsumer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var readers []*fakeReader
+	for partition := range 3 {
+		reader := &fakeReader{}
+		for offset := range 5 {
+			reader.messages = append(reader.messages, kafka.Message{Topic: "topic", Partition: partition, Offset: int64(offset)})
+		}
+		readers = append(readers, reader)
+	}
+	consumer := newPartitionsConsumer([]messageFetcher{readers[0], readers[1], readers[2]})
+
+	next := make(map[int]int64)
+	for range 15 {
+		record, err := consumer.FetchRecord(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if record.Offset != next[record.Partition] {
+			t.Errorf("Expected offset %d of partition %d, got %d", next[record.Partition], record.Partition, record.Offset)
+		}
+		next[record.Partition] = record.Offset + 1
+	}
+	for partition := range 3 {
+		if next[partition] != 5 {
+			t.Errorf("Expected 5 records of partition %d, got %d", partition, next[partition])
+		}
+	}
+
+	// No more records until cancelled
+	fetchCtx, stop := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer stop()
+	if _, err := consumer.FetchRecord(fetchCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got '%v'", err)
+	}
+
+	if err := consumer.Close(); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	for i, reader := range readers {
+		if !reader.closed.Load() {
+			t.Errorf("Expected reader of partition %d to be closed", i)
+		}
+	}
+}
+
+func TestPartitionsConsumerError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	failure := errors.New("failure")
+	consumer := newPartitionsConsumer([]messageFetcher{&fakeReader{}, &fakeReader{err: failure}})
+	defer consumer.Close()
+
+	if _, err := consumer.FetchRecord(ctx); !errors.Is(err, failure) {
+		t.Errorf("Expected '%s', got '%v'", failure, err)
+	}
+}
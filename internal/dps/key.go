@@ -0,0 +1,62 @@
+package dps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KeyStrategy decides the kafka key of a message.
+type KeyStrategy string
+
+const (
+	// KeyRandom uses a random UUID, so submissions of the same package are
+	// spread across partitions.
+	KeyRandom KeyStrategy = "random"
+	// KeyUrn uses the URN, so submissions of the same package are co-located,
+	// ordered and can be compacted.
+	KeyUrn KeyStrategy = "urn"
+	// KeyPathHash uses the hex encoded sha256 of the path.
+	KeyPathHash KeyStrategy = "path-hash"
+)
+
+func ParseKeyStrategy(s string) (KeyStrategy, error) {
+	switch k := KeyStrategy(s); k {
+	case KeyRandom, KeyUrn, KeyPathHash:
+		return k, nil
+	default:
+		return "", fmt.Errorf("unknown key strategy '%s', expected one of '%s', '%s' or '%s'", s, KeyRandom, KeyUrn, KeyPathHash)
+	}
+}
+
+// Key returns the kafka key of msg.
+func (k KeyStrategy) Key(msg Message) ([]byte, error) {
+	switch k {
+	case KeyUrn:
+		if msg.Urn == "" {
+			return nil, fmt.Errorf("message '%s' has no URN", msg.Identifier)
+		}
+		return []byte(msg.Urn), nil
+	case KeyPathHash:
+		if msg.Path == "" {
+			return nil, fmt.Errorf("message '%s' has no path", msg.Identifier)
+		}
+		sum := sha256.Sum256([]byte(msg.Path))
+		return []byte(hex.EncodeToString(sum[:])), nil
+	case KeyRandom, "":
+		return CreateUuid()
+	default:
+		return nil, fmt.Errorf("unknown key strategy '%s'", k)
+	}
+}
+
+// Balancer returns the balancer that places messages on partitions according
+// to the key strategy.
+func (k KeyStrategy) Balancer() kafka.Balancer {
+	if k == KeyRandom || k == "" {
+		return &kafka.LeastBytes{}
+	}
+	return &kafka.Hash{}
+}
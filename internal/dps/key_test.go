@@ -0,0 +1,49 @@
+package dps
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyStrategy(t *testing.T) {
+	msg := CreateMessage("/path/to/crawl-0001", "crawl-0001", ContentTypeWarc)
+	resubmission, err := CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, k := range []KeyStrategy{KeyUrn, KeyPathHash} {
+		key, err := k.Key(msg)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		other, err := k.Key(resubmission)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if !bytes.Equal(key, other) {
+			t.Errorf("Expected key strategy '%s' to give the same key for resubmissions, got '%s' and '%s'", k, key, other)
+		}
+	}
+
+	key, err := KeyRandom.Key(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	other, err := KeyRandom.Key(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if bytes.Equal(key, other) {
+		t.Errorf("Expected random keys to differ, got '%s' twice", key)
+	}
+}
+
+func TestParseKeyStrategy(t *testing.T) {
+	if _, err := ParseKeyStrategy("urn"); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if _, err := ParseKeyStrategy("unknown"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
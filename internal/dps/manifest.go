@@ -0,0 +1,80 @@
+package dps
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// File describes a file submitted as part of a package.
+type File struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"modTime"`
+	Md5     string `json:"md5"`
+	Sha256  string `json:"sha256"`
+}
+
+// CreateManifest returns a description of every regular file below dir, with
+// paths relative to dir, sorted by path.
+func CreateManifest(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		file, err := describeFile(path)
+		if err != nil {
+			return err
+		}
+		file.Path = filepath.ToSlash(rel)
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest of '%s': %w", dir, err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+func describeFile(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return File{}, err
+	}
+
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(md5Hash, sha256Hash), f); err != nil {
+		return File{}, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+
+	return File{
+		Size:    info.Size(),
+		ModTime: info.ModTime().UTC().Format(DateFormat),
+		Md5:     hex.EncodeToString(md5Hash.Sum(nil)),
+		Sha256:  hex.EncodeToString(sha256Hash.Sum(nil)),
+	}, nil
+}
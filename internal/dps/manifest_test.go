@@ -0,0 +1,52 @@
+package dps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateManifest(t *testing.T) {
+	directory, err := os.MkdirTemp("", "manifest")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	if err := os.Mkdir(filepath.Join(directory, "sub"), 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for _, name := range []string{"b.txt", filepath.Join("sub", "a.txt")} {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte("hello\n"), 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	files, err := CreateManifest(directory)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for i := range files {
+		files[i].ModTime = ""
+	}
+
+	expected := []File{
+		{
+			Path:   "b.txt",
+			Size:   6,
+			Md5:    "b1946ac92492d2347c6235b4d2611184",
+			Sha256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		},
+		{
+			Path:   "sub/a.txt",
+			Size:   6,
+			Md5:    "b1946ac92492d2347c6235b4d2611184",
+			Sha256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		},
+	}
+	if !cmp.Equal(files, expected) {
+		t.Errorf("Unexpected manifest: %s", cmp.Diff(expected, files))
+	}
+}
@@ -0,0 +1,150 @@
+package dps
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryTransport is an in-process message bus, mainly for tests. Each topic
+// has a single partition.
+type MemoryTransport struct {
+	mu     sync.Mutex
+	topics map[string][]Record
+	// committed holds the next offset of each consumer group per topic
+	committed map[string]int64
+	// changed is closed and replaced whenever a record is written
+	changed chan struct{}
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{
+		topics:    make(map[string][]Record),
+		committed: make(map[string]int64),
+		changed:   make(chan struct{}),
+	}
+}
+
+func (t *MemoryTransport) Producer(topic string, _ KeyStrategy) (Producer, error) {
+	return &memoryProducer{transport: t, topic: topic}, nil
+}
+
+func (t *MemoryTransport) Consumer(topic string, groupID string) (Consumer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := &memoryConsumer{transport: t, topic: topic, groupID: groupID}
+	if groupID != "" {
+		c.offset = t.committed[groupKey(topic, groupID)]
+	}
+	return c, nil
+}
+
+func (t *MemoryTransport) Replay(ctx context.Context, topic string, fn func(Record) error) error {
+	t.mu.Lock()
+	records := t.topics[topic]
+	t.mu.Unlock()
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Records returns the records written to topic.
+func (t *MemoryTransport) Records(topic string) []Record {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Record(nil), t.topics[topic]...)
+}
+
+func groupKey(topic string, groupID string) string {
+	return topic + "/" + groupID
+}
+
+type memoryProducer struct {
+	transport *MemoryTransport
+	topic     string
+}
+
+func (p *memoryProducer) WriteRecords(ctx context.Context, records ...Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t := p.transport
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, record := range records {
+		record.Topic = p.topic
+		record.Partition = 0
+		record.Offset = int64(len(t.topics[p.topic]))
+		if record.Time.IsZero() {
+			record.Time = time.Now().UTC()
+		}
+		t.topics[p.topic] = append(t.topics[p.topic], record)
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+	return nil
+}
+
+func (p *memoryProducer) Close() error {
+	return nil
+}
+
+type memoryConsumer struct {
+	transport *MemoryTransport
+	topic     string
+	groupID   string
+	offset    int64
+}
+
+// FetchRecord returns the next record, waiting for one to be written if necessary.
+func (c *memoryConsumer) FetchRecord(ctx context.Context) (Record, error) {
+	t := c.transport
+	for {
+		t.mu.Lock()
+		records := t.topics[c.topic]
+		if c.offset < int64(len(records)) {
+			record := records[c.offset]
+			c.offset++
+			t.mu.Unlock()
+			return record, nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (c *memoryConsumer) CommitRecords(_ context.Context, records ...Record) error {
+	if c.groupID == "" {
+		return nil
+	}
+	t := c.transport
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := groupKey(c.topic, c.groupID)
+	for _, record := range records {
+		if record.Offset+1 > t.committed[key] {
+			t.committed[key] = record.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (c *memoryConsumer) Close() error {
+	return nil
+}
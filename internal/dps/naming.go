@@ -0,0 +1,154 @@
+package dps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Placeholders of NamingScheme.IdentifierTemplate.
+const (
+	PlaceholderNamespace = "{namespace}"
+	PlaceholderCategory  = "{category}"
+	PlaceholderName      = "{name}"
+)
+
+// Uniqueness suffixes appended to identifiers.
+const (
+	SuffixUuid      = "uuid"
+	SuffixTimestamp = "timestamp"
+	SuffixNone      = "none"
+)
+
+// NamingScheme decides the content category, URN and identifier of submitted
+// packages, and which messages are owned by hermetic.
+type NamingScheme struct {
+	// ContentCategory of submitted packages. Only messages with this content
+	// category are owned.
+	ContentCategory string
+	// UrnPrefix is prepended to the expanded identifier template to form the URN.
+	UrnPrefix string
+	// Namespace replaces {namespace} in IdentifierTemplate.
+	Namespace string
+	// IdentifierTemplate is expanded with the placeholders {namespace},
+	// {category} and {name}, where name is the payload name.
+	IdentifierTemplate string
+	// Suffix makes identifiers of the same package unique: "uuid", "timestamp" or
+	// "none". Resubmissions always get a suffix, "timestamp" in place of "none".
+	Suffix string
+}
+
+// DefaultNamingScheme is the naming scheme of the web archive.
+var DefaultNamingScheme = NamingScheme{
+	ContentCategory:    "nettarkiv",
+	UrnPrefix:          "URN:NBN:",
+	Namespace:          "no-nb",
+	IdentifierTemplate: PlaceholderNamespace + "_" + PlaceholderCategory + "_" + PlaceholderName,
+	Suffix:             SuffixUuid,
+}
+
+func (s NamingScheme) Validate() error {
+	if s.ContentCategory == "" {
+		return errors.New("content category is required")
+	}
+	if s.UrnPrefix == "" {
+		return errors.New("URN prefix is required")
+	}
+	if !strings.Contains(s.IdentifierTemplate, PlaceholderName) {
+		return fmt.Errorf("identifier template '%s' must contain %s", s.IdentifierTemplate, PlaceholderName)
+	}
+	switch s.Suffix {
+	case SuffixUuid, SuffixTimestamp, SuffixNone:
+	default:
+		return fmt.Errorf("unknown identifier suffix '%s', expected one of '%s', '%s' or '%s'", s.Suffix, SuffixUuid, SuffixTimestamp, SuffixNone)
+	}
+	return nil
+}
+
+// commonPart returns the part shared by the URN and identifiers of the payload with the given name.
+func (s NamingScheme) commonPart(name string) string {
+	return strings.NewReplacer(
+		PlaceholderNamespace, s.Namespace,
+		PlaceholderCategory, s.ContentCategory,
+		PlaceholderName, name,
+	).Replace(s.IdentifierTemplate)
+}
+
+// Urn returns the URN of the payload with the given name.
+func (s NamingScheme) Urn(name string) string {
+	return s.UrnPrefix + s.commonPart(name)
+}
+
+func (s NamingScheme) identifier(commonPart string, now time.Time) string {
+	switch s.Suffix {
+	case SuffixNone:
+		return commonPart
+	case SuffixTimestamp:
+		return commonPart + "_" + now.Format("20060102T150405.000Z")
+	default:
+		return commonPart + "_" + uuid.New().String()
+	}
+}
+
+func (s NamingScheme) CreateMessage(path string, payloadDirName string, contentType string) Message {
+	now := time.Now().UTC()
+	commonPart := s.commonPart(payloadDirName)
+
+	return Message{
+		Date:            now.Format(DateFormat),
+		ContentCategory: s.ContentCategory,
+		ContentType:     contentType,
+		Identifier:      s.identifier(commonPart, now),
+		Urn:             s.UrnPrefix + commonPart,
+		Path:            path,
+	}
+}
+
+// CreateResubmission returns a message for submitting the same package as
+// previous again, with a new identifier linked to the previous one. The URN of
+// previous must have the URN prefix of the scheme, as the new identifier is
+// derived from the rest of it.
+func (s NamingScheme) CreateResubmission(previous Message) (Message, error) {
+	now := time.Now().UTC()
+	commonPart, ok := strings.CutPrefix(previous.Urn, s.UrnPrefix)
+	if !ok || commonPart == "" {
+		return Message{}, fmt.Errorf("URN '%s' of '%s' does not start with the URN prefix '%s'", previous.Urn, previous.Identifier, s.UrnPrefix)
+	}
+	// Without a suffix the resubmission would get the identifier of the
+	// previous submission, and the responses to them could not be told apart
+	if s.Suffix == SuffixNone {
+		s.Suffix = SuffixTimestamp
+	}
+
+	return Message{
+		Date:               now.Format(DateFormat),
+		ContentCategory:    previous.ContentCategory,
+		ContentType:        previous.ContentType,
+		Identifier:         s.identifier(commonPart, now),
+		Urn:                previous.Urn,
+		Path:               previous.Path,
+		PreviousIdentifier: previous.Identifier,
+	}, nil
+}
+
+// IsOwned reports whether the message belongs to the content category of the scheme.
+func (s NamingScheme) IsOwned(message *Message) bool {
+	return message.ContentCategory == s.ContentCategory
+}
+
+// IsOwnedSubmission reports whether the message is owned and of a content type sent by hermetic.
+func (s NamingScheme) IsOwnedSubmission(message *Message) bool {
+	if !s.IsOwned(message) {
+		return false
+	}
+
+	switch message.ContentType {
+	case ContentTypeWarc, ContentTypeAcquisition:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+package dps
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNamingScheme(t *testing.T) {
+	scheme := NamingScheme{
+		ContentCategory:    "aviser",
+		UrnPrefix:          "URN:NBN:",
+		Namespace:          "no-nb",
+		IdentifierTemplate: "{namespace}-{category}-{name}",
+		Suffix:             SuffixTimestamp,
+	}
+	if err := scheme.Validate(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	msg := scheme.CreateMessage("/path/to/paper", "paper", ContentTypeWarc)
+
+	if msg.Urn != "URN:NBN:no-nb-aviser-paper" {
+		t.Errorf("Expected URN:NBN:no-nb-aviser-paper, got %s", msg.Urn)
+	}
+	if msg.Urn != scheme.Urn("paper") {
+		t.Errorf("Expected %s, got %s", scheme.Urn("paper"), msg.Urn)
+	}
+	if !regexp.MustCompile(`^no-nb-aviser-paper_[0-9]{8}T[0-9]{6}\.[0-9]{3}Z$`).MatchString(msg.Identifier) {
+		t.Errorf("Unexpected identifier %s", msg.Identifier)
+	}
+	if !scheme.IsOwned(&msg) || !scheme.IsOwnedSubmission(&msg) {
+		t.Errorf("Expected %v to be owned by %v", msg, scheme)
+	}
+	if DefaultNamingScheme.IsOwned(&msg) {
+		t.Errorf("Expected %v not to be owned by the default scheme", msg)
+	}
+
+	resubmission, err := scheme.CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !regexp.MustCompile(`^no-nb-aviser-paper_`).MatchString(resubmission.Identifier) {
+		t.Errorf("Unexpected identifier %s", resubmission.Identifier)
+	}
+
+	scheme.Suffix = SuffixNone
+	msg = scheme.CreateMessage("/path/to/paper", "paper", ContentTypeWarc)
+	if msg.Identifier != "no-nb-aviser-paper" {
+		t.Errorf("Expected no-nb-aviser-paper, got %s", msg.Identifier)
+	}
+	resubmission, err = scheme.CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if resubmission.Identifier == msg.Identifier || !regexp.MustCompile(`^no-nb-aviser-paper_[0-9]{8}T`).MatchString(resubmission.Identifier) {
+		t.Errorf("Expected resubmission of %s to have a unique identifier, got %s", msg.Identifier, resubmission.Identifier)
+	}
+
+	// The identifier can not be derived from a URN with another prefix
+	scheme.UrnPrefix = "urn:other:"
+	if _, err := scheme.CreateResubmission(msg); err == nil {
+		t.Errorf("Expected error for URN %s with prefix %s, got nil", msg.Urn, scheme.UrnPrefix)
+	}
+}
+
+func TestNamingSchemeInvalid(t *testing.T) {
+	invalid := []NamingScheme{
+		{UrnPrefix: "URN:NBN:", IdentifierTemplate: "{name}", Suffix: SuffixUuid},
+		{ContentCategory: "c", UrnPrefix: "URN:NBN:", IdentifierTemplate: "{category}", Suffix: SuffixUuid},
+		{ContentCategory: "c", UrnPrefix: "URN:NBN:", IdentifierTemplate: "{name}", Suffix: "random"},
+	}
+	for _, scheme := range invalid {
+		if err := scheme.Validate(); err == nil {
+			t.Errorf("Expected error for %v, got nil", scheme)
+		}
+	}
+	if err := DefaultNamingScheme.Validate(); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
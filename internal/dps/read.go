@@ -3,12 +3,8 @@ package dps
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"net"
 	"time"
-
-	"github.com/segmentio/kafka-go"
 )
 
 const (
@@ -16,106 +12,93 @@ const (
 	ContentTypeAcquisition = "acquisition"
 )
 
-func getKafkaPartitionLeader(kafkaEndpoints []string, kafkaTopic string) (*kafka.Conn, error) {
-	if len(kafkaEndpoints) == 0 {
-		return nil, errors.New("no kafka endpoints provided")
-	}
-
-	conn, err := net.Dial("tcp", kafkaEndpoints[0])
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial: %w", err)
-	}
-
-	kafkaConn := kafka.NewConn(conn, kafkaTopic, 0)
-	defer kafkaConn.Close()
-
-	partitions, err := kafkaConn.ReadPartitions(kafkaTopic)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read partitions: %w", err)
-	}
-
-	if len(partitions) != 1 {
-		return nil, fmt.Errorf("expected exactly 1 partition, got '%d'", len(partitions))
-	}
-
-	connLeader, err := net.Dial("tcp", fmt.Sprintf("%s:%d", partitions[0].Leader.Host, partitions[0].Leader.Port))
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial tcp: %w", err)
-	}
-
-	return kafka.NewConn(connLeader, kafkaTopic, 0), nil
-}
-
-func ReadLatestMessages(ctx context.Context, kafkaEndpoints []string, kafkaTopic string, fn func(*Message) error) error {
-	conn, err := getKafkaPartitionLeader(kafkaEndpoints, kafkaTopic)
-	if err != nil {
-		return fmt.Errorf("failed to get kafka partition leader: %w", err)
-	}
-	firstOffset, lastOffset, err := conn.ReadOffsets()
-	if err != nil {
-		return fmt.Errorf("failed to get first and last offset: %w", err)
-	}
-	if err := conn.Close(); err != nil {
-		return fmt.Errorf("failed to close connection to leader: %w", err)
-	}
-
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: kafkaEndpoints,
-		Topic:   kafkaTopic,
-	})
-	defer reader.Close()
-
+// ReadLatestMessages replays every message of the topic, up to the last
+// message at the time of calling. Messages may be interleaved across
+// partitions, but fn is never called concurrently.
+func ReadLatestMessages(ctx context.Context, transport Transport, topic string, fn func(*Message) error) error {
 	readTimeout := 5 * time.Minute
 	ctx, cancel := context.WithTimeout(ctx, readTimeout)
 	defer cancel()
 
-	err = reader.SetOffset(firstOffset)
-	if err != nil {
-		return fmt.Errorf("failed to set kafka reader offset '%d': %w", firstOffset, err)
-	}
-	for reader.Offset() < lastOffset {
-		kafkaMsg, err := reader.ReadMessage(ctx)
-		if err != nil {
-			return err
-		}
-		if kafkaMsg.Value == nil {
-			continue
+	return transport.Replay(ctx, topic, func(record Record) error {
+		if record.Value == nil {
+			return nil
 		}
 		var msg Message
-		err = json.Unmarshal(kafkaMsg.Value, &msg)
+		err := json.Unmarshal(record.Value, &msg)
 		if err != nil {
-			return fmt.Errorf("failed to unmarshal kafka message: %w", err)
+			return fmt.Errorf("failed to unmarshal message at offset %d: %w", record.Offset, err)
 		}
 		err = fn(&msg)
 		if err != nil {
 			return fmt.Errorf("failed to process message: %w", err)
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
-func NextMessage(ctx context.Context, reader *kafka.Reader, filter func(*Message) bool) (*KafkaMessage, error) {
+// NextMessage returns the next message accepted by filter. The returned
+// message is not committed, so the caller must commit message.Record once it
+// has been processed. Messages not accepted by filter are committed and
+// skipped. A message that is not valid JSON, or is accepted by filter but not
+// valid against the response schema, is returned as an error without being
+// committed if deadLetters is nil, and handed to deadLetters, committed and
+// skipped otherwise.
+func NextMessage(ctx context.Context, consumer Consumer, filter func(*Message) bool, deadLetters *DeadLetters) (*KafkaMessage, error) {
+	return NextMessageOf(ctx, consumer, SchemaResponse, filter, deadLetters)
+}
+
+// NextMessageOf is like NextMessage, but validates messages against the schema of the given kind.
+func NextMessageOf(ctx context.Context, consumer Consumer, kind SchemaKind, filter func(*Message) bool, deadLetters *DeadLetters) (*KafkaMessage, error) {
 	for {
-		message, err := reader.ReadMessage(ctx)
+		record, err := consumer.FetchRecord(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read message: %w", err)
 		}
 
-		var response Message
-
-		err = json.Unmarshal(message.Value, &response)
+		response, err := parseMessage(record, kind, filter)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal kafka message: %w", err)
+			if deadLetters == nil {
+				return nil, err
+			}
+			if err := deadLetters.Handle(ctx, record, err); err != nil {
+				return nil, err
+			}
 		}
-
-		if !filter(&response) {
+		if response == nil {
+			if err := consumer.CommitRecords(ctx, record); err != nil {
+				return nil, fmt.Errorf("failed to commit message at offset %d: %w", record.Offset, err)
+			}
 			continue
 		}
 
 		return &KafkaMessage{
-			Offset: message.Offset,
-			Key:    string(message.Key),
-			Value:  response,
+			Offset:  record.Offset,
+			Key:     string(record.Key),
+			Headers: record.Headers,
+			Value:   *response,
+			Record:  record,
 		}, nil
 	}
 }
+
+// parseMessage returns the message of the record, or nil if it is not
+// accepted by filter.
+func parseMessage(record Record, kind SchemaKind, filter func(*Message) bool) (*Message, error) {
+	var response Message
+
+	err := json.Unmarshal(record.Value, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message at offset %d: %w", record.Offset, err)
+	}
+
+	if !filter(&response) {
+		return nil, nil
+	}
+
+	if err := ValidateSchema(kind, record.Headers[HeaderSchemaVersion], record.Value); err != nil {
+		return nil, fmt.Errorf("invalid message at offset %d: %w", record.Offset, err)
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,89 @@
+package dps
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaKind is the kind of message described by a schema.
+type SchemaKind string
+
+const (
+	// SchemaTransfer describes messages sent by hermetic to the transfer topic.
+	SchemaTransfer SchemaKind = "transfer"
+	// SchemaResponse describes messages received from DPS on the confirm and reject topics.
+	SchemaResponse SchemaKind = "response"
+)
+
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+var (
+	schemaMu       sync.Mutex
+	compiledSchema = make(map[string]*jsonschema.Schema)
+)
+
+func schemaFileName(kind SchemaKind, version string) string {
+	return fmt.Sprintf("schema/%s.v%s.json", kind, version)
+}
+
+// Schema returns the JSON schema document of the given kind and version.
+func Schema(kind SchemaKind, version string) ([]byte, error) {
+	b, err := schemaFS.ReadFile(schemaFileName(kind, version))
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema '%s' version '%s'", kind, version)
+	}
+	return b, nil
+}
+
+func compileSchema(kind SchemaKind, version string) (*jsonschema.Schema, error) {
+	name := schemaFileName(kind, version)
+
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+
+	if s, ok := compiledSchema[name]; ok {
+		return s, nil
+	}
+	b, err := Schema(kind, version)
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(name, bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to load schema '%s': %w", name, err)
+	}
+	s, err := compiler.Compile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema '%s': %w", name, err)
+	}
+	compiledSchema[name] = s
+	return s, nil
+}
+
+// ValidateSchema validates the JSON document data against the schema of the
+// given kind and version. An empty version means SchemaVersion.
+func ValidateSchema(kind SchemaKind, version string, data []byte) error {
+	if version == "" {
+		version = SchemaVersion
+	}
+	s, err := compileSchema(kind, version)
+	if err != nil {
+		return err
+	}
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if err := s.Validate(doc); err != nil {
+		return fmt.Errorf("message does not conform to %s schema version %s: %w", kind, version, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package dps
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateSchemaTransfer(t *testing.T) {
+	msg := CreateMessage("/path/to/crawl-0001", "crawl-0001", ContentTypeWarc)
+	msg.Files = []File{{Path: "crawl-0001.warc.gz", Size: 1, ModTime: msg.Date, Md5: "b1946ac92492d2347c6235b4d2611184", Sha256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"}}
+	resubmission, err := CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	// The URN prefix is configurable
+	scheme := DefaultNamingScheme
+	scheme.UrnPrefix = "urn:example:"
+	other := scheme.CreateMessage("/path/to/crawl-0001", "crawl-0001", ContentTypeWarc)
+
+	for _, m := range []Message{msg, resubmission, other} {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if err := ValidateSchema(SchemaTransfer, "", data); err != nil {
+			t.Errorf("Expected no error, got '%s'", err)
+		}
+	}
+}
+
+func TestValidateSchemaTransferInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		`{"date":"2024-01-03T09:29:16.000","identifier":"id","urn":"URN:NBN:id","path":"/path","contentType":"warc","contentCategory":"nettarkiv","unknown":"field"}`,
+		`{"date":"yesterday","identifier":"id","urn":"URN:NBN:id","path":"/path","contentType":"warc","contentCategory":"nettarkiv"}`,
+		`not json`,
+	} {
+		if err := ValidateSchema(SchemaTransfer, "", []byte(data)); err == nil {
+			t.Errorf("Expected error for '%s', got nil", data)
+		}
+	}
+}
+
+func TestValidateSchemaResponse(t *testing.T) {
+	valid := `{"date":"date","identifier":"id","urn":"urn","path":"path","contentType":"warc","contentCategory":"nettarkiv","checks":[{"Status":"FAILED","Message":"m","Reason":"r","File":"f"}]}`
+	if err := ValidateSchema(SchemaResponse, "", []byte(valid)); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+
+	renamed := `{"date":"date","identifier":"id","urn":"urn","path":"path","contentType":"warc","contentCategory":"nettarkiv","checks":[{"Status":"FAILED","Cause":"r"}]}`
+	if err := ValidateSchema(SchemaResponse, "", []byte(renamed)); err == nil {
+		t.Errorf("Expected error for renamed check field, got nil")
+	}
+}
+
+func TestSchemaUnknownVersion(t *testing.T) {
+	if _, err := Schema(SchemaTransfer, "999"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
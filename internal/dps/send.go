@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 
 	"github.com/google/uuid"
-	"github.com/segmentio/kafka-go"
 )
 
 func CreateUuid() ([]byte, error) {
@@ -13,8 +12,8 @@ func CreateUuid() ([]byte, error) {
 	return id.MarshalText()
 }
 
-func Send(ctx context.Context, w *kafka.Writer, msg Message) error {
-	key, err := CreateUuid()
+func Send(ctx context.Context, producer Producer, msg Message, keyStrategy KeyStrategy) error {
+	key, err := keyStrategy.Key(msg)
 	if err != nil {
 		return err
 	}
@@ -23,11 +22,15 @@ func Send(ctx context.Context, w *kafka.Writer, msg Message) error {
 	if err != nil {
 		return err
 	}
+	if err := ValidateSchema(SchemaTransfer, SchemaVersion, value); err != nil {
+		return err
+	}
 
-	message := kafka.Message{
-		Key:   key,
-		Value: value,
+	record := Record{
+		Key:     key,
+		Value:   value,
+		Headers: createHeaders(msg),
 	}
 
-	return w.WriteMessages(ctx, message)
+	return producer.WriteRecords(ctx, record)
 }
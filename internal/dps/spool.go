@@ -0,0 +1,361 @@
+package dps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spoolFileSuffix = ".json"
+	spoolGroupsDir  = ".groups"
+	// DefaultSpoolPollInterval is how often consumers look for new files in the spool.
+	DefaultSpoolPollInterval = time.Second
+)
+
+// SpoolTransport carries messages as files in a directory, for sites without
+// network access to kafka. Every topic is a subdirectory of Dir holding one
+// JSON file per record, named by its zero padded offset, e.g.
+// <dir>/<topic>/00000000000000000042.json. Committed positions of consumer
+// groups are kept in <dir>/<topic>/.groups/<group>.
+type SpoolTransport struct {
+	Dir          string
+	PollInterval time.Duration
+
+	mu sync.Mutex
+}
+
+// spoolRecord is the content of a spool file. Values that are valid JSON are
+// stored as is, other values are stored base64 encoded.
+type spoolRecord struct {
+	Time        time.Time         `json:"time"`
+	Key         string            `json:"key,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Value       json.RawMessage   `json:"value,omitempty"`
+	ValueBase64 []byte            `json:"valueBase64,omitempty"`
+}
+
+func NewSpoolTransport(dir string) *SpoolTransport {
+	return &SpoolTransport{Dir: dir, PollInterval: DefaultSpoolPollInterval}
+}
+
+func (t *SpoolTransport) Producer(topic string, _ KeyStrategy) (Producer, error) {
+	dir, err := t.topicDir(topic)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &spoolProducer{transport: t, topic: topic, dir: dir}, nil
+}
+
+func (t *SpoolTransport) Consumer(topic string, groupID string) (Consumer, error) {
+	dir, err := t.topicDir(topic)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(groupID, `/\`) || groupID == "." || groupID == ".." {
+		return nil, fmt.Errorf("invalid consumer group id '%s'", groupID)
+	}
+	c := &spoolConsumer{transport: t, topic: topic, dir: dir, groupID: groupID}
+	if groupID != "" {
+		c.offset, err = readSpoolOffset(c.groupFile())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (t *SpoolTransport) Replay(ctx context.Context, topic string, fn func(Record) error) error {
+	dir, err := t.topicDir(topic)
+	if err != nil {
+		return err
+	}
+	offsets, err := spoolOffsets(dir)
+	if err != nil {
+		return err
+	}
+	for _, offset := range offsets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := readSpoolRecord(dir, topic, offset)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *SpoolTransport) topicDir(topic string) (string, error) {
+	if topic == "" || topic == "." || topic == ".." || strings.ContainsAny(topic, `/\`) {
+		return "", fmt.Errorf("invalid topic name '%s'", topic)
+	}
+	return filepath.Join(t.Dir, topic), nil
+}
+
+func spoolFileName(offset int64) string {
+	return fmt.Sprintf("%020d%s", offset, spoolFileSuffix)
+}
+
+// spoolOffsets returns the offsets of the records in dir in ascending order.
+func spoolOffsets(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	var offsets []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolFileSuffix) {
+			continue
+		}
+		offset, err := strconv.ParseInt(strings.TrimSuffix(name, spoolFileSuffix), 10, 64)
+		if err != nil || offset < 0 {
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets, nil
+}
+
+func readSpoolRecord(dir string, topic string, offset int64) (Record, error) {
+	path := filepath.Join(dir, spoolFileName(offset))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to read spool file: %w", err)
+	}
+	var sr spoolRecord
+	if err := json.Unmarshal(data, &sr); err != nil {
+		return Record{}, fmt.Errorf("failed to parse spool file '%s': %w", path, err)
+	}
+	record := Record{
+		Topic:   topic,
+		Offset:  offset,
+		Time:    sr.Time,
+		Headers: sr.Headers,
+		Value:   sr.ValueBase64,
+	}
+	if sr.Key != "" {
+		record.Key = []byte(sr.Key)
+	}
+	if sr.Value != nil {
+		record.Value = sr.Value
+	}
+	return record, nil
+}
+
+func readSpoolOffset(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read committed offset: %w", err)
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse committed offset in '%s': %w", path, err)
+	}
+	return offset, nil
+}
+
+// writeTempFile writes data to a new hidden file in dir and returns its path.
+func writeTempFile(dir string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(dir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path and renames it to path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := writeTempFile(filepath.Dir(path), data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	return os.Rename(tmp, path)
+}
+
+type spoolProducer struct {
+	transport *SpoolTransport
+	topic     string
+	dir       string
+}
+
+func (p *spoolProducer) WriteRecords(ctx context.Context, records ...Record) error {
+	p.transport.mu.Lock()
+	defer p.transport.mu.Unlock()
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.write(record); err != nil {
+			return fmt.Errorf("failed to write record to spool topic '%s': %w", p.topic, err)
+		}
+	}
+	return nil
+}
+
+// write links the record into the spool under the next free offset. Linking
+// fails if the name is taken, so concurrent writers never overwrite each
+// other and readers never see a partially written file.
+func (p *spoolProducer) write(record Record) error {
+	sr := spoolRecord{Time: record.Time, Key: string(record.Key), Headers: record.Headers}
+	if sr.Time.IsZero() {
+		sr.Time = time.Now().UTC()
+	}
+	if json.Valid(record.Value) {
+		sr.Value = record.Value
+	} else {
+		sr.ValueBase64 = record.Value
+	}
+	data, err := json.Marshal(sr)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := writeTempFile(p.dir, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	offsets, err := spoolOffsets(p.dir)
+	if err != nil {
+		return err
+	}
+	var next int64
+	if len(offsets) > 0 {
+		next = offsets[len(offsets)-1] + 1
+	}
+	for {
+		err := os.Link(tmp, filepath.Join(p.dir, spoolFileName(next)))
+		if errors.Is(err, os.ErrExist) {
+			next++
+			continue
+		}
+		return err
+	}
+}
+
+func (p *spoolProducer) Close() error {
+	return nil
+}
+
+type spoolConsumer struct {
+	transport *SpoolTransport
+	topic     string
+	dir       string
+	groupID   string
+	offset    int64
+}
+
+func (c *spoolConsumer) groupFile() string {
+	return filepath.Join(c.dir, spoolGroupsDir, c.groupID)
+}
+
+// FetchRecord returns the record at the next offset, or the first record after
+// it if there is a gap, polling the spool until one is written.
+func (c *spoolConsumer) FetchRecord(ctx context.Context) (Record, error) {
+	for {
+		_, err := os.Stat(filepath.Join(c.dir, spoolFileName(c.offset)))
+		if err == nil {
+			return c.fetch(c.offset)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return Record{}, fmt.Errorf("failed to read spool: %w", err)
+		}
+
+		offsets, err := spoolOffsets(c.dir)
+		if err != nil {
+			return Record{}, err
+		}
+		i := sort.Search(len(offsets), func(i int) bool { return offsets[i] >= c.offset })
+		if i < len(offsets) {
+			return c.fetch(offsets[i])
+		}
+
+		interval := c.transport.PollInterval
+		if interval <= 0 {
+			interval = DefaultSpoolPollInterval
+		}
+		select {
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *spoolConsumer) fetch(offset int64) (Record, error) {
+	record, err := readSpoolRecord(c.dir, c.topic, offset)
+	if err != nil {
+		return Record{}, err
+	}
+	c.offset = offset + 1
+	return record, nil
+}
+
+func (c *spoolConsumer) CommitRecords(_ context.Context, records ...Record) error {
+	if c.groupID == "" || len(records) == 0 {
+		return nil
+	}
+	c.transport.mu.Lock()
+	defer c.transport.mu.Unlock()
+
+	path := c.groupFile()
+	committed, err := readSpoolOffset(path)
+	if err != nil {
+		return err
+	}
+	next := committed
+	for _, record := range records {
+		if record.Offset+1 > next {
+			next = record.Offset + 1
+		}
+	}
+	if next == committed {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	if err := writeFileAtomic(path, []byte(strconv.FormatInt(next, 10)+"\n")); err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+	return nil
+}
+
+func (c *spoolConsumer) Close() error {
+	return nil
+}
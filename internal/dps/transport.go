@@ -0,0 +1,45 @@
+package dps
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a message as carried by a transport.
+type Record struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	// Time is when the record was written to the topic.
+	Time    time.Time
+	Key     []byte
+	Headers map[string]string
+	Value   []byte
+}
+
+// Producer writes records to a topic.
+type Producer interface {
+	WriteRecords(ctx context.Context, records ...Record) error
+	Close() error
+}
+
+// Consumer reads records from a topic. Records are fetched in order, and the
+// position of a consumer group only advances when records are committed.
+type Consumer interface {
+	FetchRecord(ctx context.Context) (Record, error)
+	CommitRecords(ctx context.Context, records ...Record) error
+	Close() error
+}
+
+// Transport carries messages between hermetic and DPS.
+type Transport interface {
+	// Producer returns a producer for topic that places records according to keyStrategy.
+	Producer(topic string, keyStrategy KeyStrategy) (Producer, error)
+	// Consumer returns a consumer of topic. Consumers with the same non-empty
+	// groupID share their committed position, other consumers start from the
+	// first record.
+	Consumer(topic string, groupID string) (Consumer, error)
+	// Replay calls fn for every record of topic, up to the last record at the
+	// time of calling. fn is never called concurrently.
+	Replay(ctx context.Context, topic string, fn func(Record) error) error
+}
@@ -0,0 +1,186 @@
+package dps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func testTransport(t *testing.T, transport Transport) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	producer, err := transport.Producer("transfer", KeyRandom)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer producer.Close()
+
+	msgs := []Message{
+		CreateMessage("/root/a", "a", ContentTypeWarc),
+		CreateMessage("/root/b", "b", ContentTypeWarc),
+	}
+	for _, msg := range msgs {
+		if err := Send(ctx, producer, msg, KeyUrn); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	if err := producer.WriteRecords(ctx, Record{Value: []byte("not json")}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var records []Record
+	err = transport.Replay(ctx, "transfer", func(record Record) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+	if string(records[0].Key) != msgs[0].Urn {
+		t.Errorf("Expected key %s, got %s", msgs[0].Urn, records[0].Key)
+	}
+	if records[1].Headers[HeaderCorrelationID] != msgs[1].Identifier {
+		t.Errorf("Expected correlation id %s, got %v", msgs[1].Identifier, records[1].Headers)
+	}
+	if string(records[2].Value) != "not json" {
+		t.Errorf("Expected value 'not json', got '%s'", records[2].Value)
+	}
+
+	consumer, err := transport.Consumer("transfer", "group")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	first, err := consumer.FetchRecord(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := consumer.CommitRecords(ctx, first); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if _, err := consumer.FetchRecord(ctx); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	consumer.Close()
+
+	// The second record was not committed, so it is fetched again by the group
+	consumer, err = transport.Consumer("transfer", "group")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer consumer.Close()
+	record, err := consumer.FetchRecord(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if record.Offset != records[1].Offset {
+		t.Errorf("Expected offset %d, got %d", records[1].Offset, record.Offset)
+	}
+
+	// Fetching waits for new records
+	if _, err := consumer.FetchRecord(ctx); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = Send(ctx, producer, CreateMessage("/root/c", "c", ContentTypeWarc), KeyRandom)
+	}()
+	record, err = consumer.FetchRecord(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if record.Headers[HeaderCorrelationID] == "" {
+		t.Errorf("Expected record with correlation id, got %v", record)
+	}
+
+	shortCtx, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if _, err := consumer.FetchRecord(shortCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got '%v'", err)
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	testTransport(t, NewMemoryTransport())
+}
+
+func TestSpoolTransport(t *testing.T) {
+	dir, err := os.MkdirTemp("", "spool")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport := NewSpoolTransport(dir)
+	transport.PollInterval = 10 * time.Millisecond
+	testTransport(t, transport)
+
+	if _, err := transport.Producer("../outside", KeyRandom); err == nil {
+		t.Errorf("Expected error for invalid topic, got nil")
+	}
+}
+
+func TestNextMessage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewMemoryTransport()
+	producer, _ := transport.Producer("confirm", KeyRandom)
+
+	other := CreateMessage("/root/a", "a", ContentTypeWarc)
+	other.ContentCategory = "other"
+	owned := CreateMessage("/root/b", "b", ContentTypeWarc)
+	for _, msg := range []Message{other, owned} {
+		if err := Send(ctx, producer, msg, KeyRandom); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	consumer, _ := transport.Consumer("confirm", "group")
+	message, err := NextMessage(ctx, consumer, IsWebArchiveOwned, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if message.Value.Identifier != owned.Identifier {
+		t.Errorf("Expected %s, got %s", owned.Identifier, message.Value.Identifier)
+	}
+	if message.Offset != 1 {
+		t.Errorf("Expected offset 1, got %d", message.Offset)
+	}
+
+	// The returned message is delivered again until it is committed
+	consumer, _ = transport.Consumer("confirm", "group")
+	message, err = NextMessage(ctx, consumer, IsWebArchiveOwned, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if message.Offset != 1 {
+		t.Errorf("Expected offset 1 to be delivered again, got %d", message.Offset)
+	}
+	if err := consumer.CommitRecords(ctx, message.Record); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	consumer, _ = transport.Consumer("confirm", "group")
+	shortCtx, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if _, err := NextMessage(shortCtx, consumer, IsWebArchiveOwned, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected no more messages, got '%v'", err)
+	}
+
+	var identifiers []string
+	err = ReadLatestMessages(ctx, transport, "confirm", func(msg *Message) error {
+		identifiers = append(identifiers, msg.Identifier)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(identifiers) != 2 {
+		t.Errorf("Expected 2 messages, got %v", identifiers)
+	}
+}
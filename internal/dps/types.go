@@ -1,10 +1,7 @@
 package dps
 
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
+// DateFormat is the layout of Message.Date.
+const DateFormat = "2006-01-02T15:04:05.000"
 
 type Check struct {
 	Status  string
@@ -21,44 +18,36 @@ type Message struct {
 	ContentType     string  `json:"contentType"`
 	ContentCategory string  `json:"contentCategory"`
 	Checks          []Check `json:"checks,omitempty"`
+	Files           []File  `json:"files,omitempty"`
+	// PreviousIdentifier is the identifier of the submission this message
+	// replaces, if it is a resubmission.
+	PreviousIdentifier string `json:"previousIdentifier,omitempty"`
 }
 
 type KafkaMessage struct {
-	Offset int64
-	Key    string
-	Value  Message
+	Offset  int64
+	Key     string
+	Headers map[string]string
+	Value   Message
+	// Record is the record the message was read from, to be committed when
+	// the message has been processed.
+	Record Record
 }
 
 func CreateMessage(path string, payloadDirName string, contentType string) Message {
-	date := time.Now().UTC().Format("2006-01-02T15:04:05.000")
-	contentCategory := "nettarkiv"
-	commonPart := "no-nb_" + contentCategory + "_" + payloadDirName
-	identifier := commonPart + "_" + uuid.New().String()
-	urn := "URN:NBN:" + commonPart
+	return DefaultNamingScheme.CreateMessage(path, payloadDirName, contentType)
+}
 
-	return Message{
-		Date:            date,
-		ContentCategory: contentCategory,
-		ContentType:     contentType,
-		Identifier:      identifier,
-		Urn:             urn,
-		Path:            path,
-	}
+// CreateResubmission returns a message for submitting the same package as
+// previous again, with a new identifier linked to the previous one.
+func CreateResubmission(previous Message) (Message, error) {
+	return DefaultNamingScheme.CreateResubmission(previous)
 }
 
 func IsWebArchiveOwned(message *Message) bool {
-	return message.ContentCategory == "nettarkiv"
+	return DefaultNamingScheme.IsOwned(message)
 }
 
 func IsWebArchiveMessage(message *Message) bool {
-	if !IsWebArchiveOwned(message) {
-		return false
-	}
-
-	switch message.ContentType {
-	case ContentTypeWarc, ContentTypeAcquisition:
-		return true
-	default:
-		return false
-	}
+	return DefaultNamingScheme.IsOwnedSubmission(message)
 }
@@ -102,3 +102,29 @@ func TestCreatePackage(t *testing.T) {
 		t.Errorf("Expected %s to be before %s", date, expectedDate)
 	}
 }
+
+func TestCreateResubmission(t *testing.T) {
+	previous := CreateMessage("/path/to/crawl-0001", "crawl-0001", ContentTypeWarc)
+	previous.Files = []File{{Path: "crawl-0001.warc.gz"}}
+
+	message, err := CreateResubmission(previous)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if message.PreviousIdentifier != previous.Identifier {
+		t.Errorf("Expected previous identifier %s, got %s", previous.Identifier, message.PreviousIdentifier)
+	}
+	if message.Identifier == previous.Identifier {
+		t.Errorf("Expected new identifier, got %s", message.Identifier)
+	}
+	if !regexp.MustCompile(`^no-nb_nettarkiv_crawl-0001_[a-z0-9-]{36}$`).MatchString(message.Identifier) {
+		t.Errorf("Unexpected identifier %s", message.Identifier)
+	}
+	if message.Urn != previous.Urn || message.Path != previous.Path || message.ContentType != previous.ContentType || message.ContentCategory != previous.ContentCategory {
+		t.Errorf("Expected %v to describe the same package as %v", message, previous)
+	}
+	if message.Files != nil {
+		t.Errorf("Expected no manifest, got %v", message.Files)
+	}
+}
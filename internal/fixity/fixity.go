@@ -0,0 +1,141 @@
+package fixity
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumFileName is the name of the checksum file accompanying each payload directory.
+const ChecksumFileName = "checksum_transferred.md5"
+
+// Entry is a single line of an md5sum formatted checksum file.
+type Entry struct {
+	Digest string
+	Path   string
+}
+
+// ParseMD5 parses checksum lines in the format produced by md5sum, in text ("<digest>  <path>")
+// or binary ("<digest> *<path>") mode.
+func ParseMD5(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		digest, path, ok := strings.Cut(text, " ")
+		if !ok || len(path) < 2 || (path[0] != ' ' && path[0] != '*') {
+			return nil, fmt.Errorf("line %d: malformed checksum line '%s'", line, text)
+		}
+		path = path[1:]
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != 2*md5.Size {
+			return nil, fmt.Errorf("line %d: invalid md5 digest '%s'", line, digest)
+		}
+		path = filepath.Clean(path)
+		if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("line %d: path '%s' is outside of directory", line, path)
+		}
+		entries = append(entries, Entry{Digest: strings.ToLower(digest), Path: path})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadMD5File parses the checksum file at path.
+func ReadMD5File(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := ParseMD5(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+	return entries, nil
+}
+
+// MD5 returns the hex encoded md5 digest of the file at path.
+func MD5(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyDirectory checks every file in dir against the digests listed in the
+// checksum file of dir. It fails if the checksum file is missing, if a listed
+// file is missing or has a different digest, or if a file in dir has no digest.
+// Files named in extra, such as a marker file, may be present without a digest.
+func VerifyDirectory(dir string, extra ...string) error {
+	entries, err := ReadMD5File(filepath.Join(dir, ChecksumFileName))
+	if err != nil {
+		return fmt.Errorf("failed to read checksum file: %w", err)
+	}
+
+	allowed := make(map[string]bool, len(extra))
+	for _, name := range extra {
+		allowed[filepath.Clean(name)] = true
+	}
+
+	var errs []error
+	listed := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		listed[entry.Path] = true
+		digest, err := MD5(filepath.Join(dir, entry.Path))
+		if errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("file '%s' is listed in %s but missing", entry.Path, ChecksumFileName))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if digest != entry.Digest {
+			errs = append(errs, fmt.Errorf("file '%s' has md5 '%s', expected '%s'", entry.Path, digest, entry.Digest))
+		}
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel != ChecksumFileName && !listed[rel] && !allowed[rel] {
+			errs = append(errs, fmt.Errorf("file '%s' has no digest in %s", rel, ChecksumFileName))
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to walk '%s': %w", dir, err))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("fixity check of '%s' failed: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package fixity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// md5 of "hello\n"
+const helloDigest = "b1946ac92492d2347c6235b4d2611184"
+
+func createDirectory(t *testing.T, checksums string, files map[string]string) string {
+	t.Helper()
+	directory, err := os.MkdirTemp("", "fixity")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(directory) })
+
+	files[ChecksumFileName] = checksums
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	return directory
+}
+
+func TestParseMD5(t *testing.T) {
+	entries, err := ParseMD5(strings.NewReader(helloDigest + "  a.warc.gz\n" + strings.ToUpper(helloDigest) + " *./b.txt\n\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := []Entry{{Digest: helloDigest, Path: "a.warc.gz"}, {Digest: helloDigest, Path: "b.txt"}}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("Expected '%v', got '%v'", expected[i], entries[i])
+		}
+	}
+}
+
+func TestParseMD5Invalid(t *testing.T) {
+	for _, line := range []string{
+		"not-a-digest  a.warc.gz",
+		helloDigest,
+		helloDigest + "  ../a.warc.gz",
+		helloDigest + "  /etc/passwd",
+	} {
+		if _, err := ParseMD5(strings.NewReader(line)); err == nil {
+			t.Errorf("Expected error for line '%s', got nil", line)
+		}
+	}
+}
+
+func TestVerifyDirectory(t *testing.T) {
+	directory := createDirectory(t, helloDigest+"  a.warc.gz\n", map[string]string{"a.warc.gz": "hello\n"})
+	if err := VerifyDirectory(directory); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestVerifyDirectoryMismatch(t *testing.T) {
+	directory := createDirectory(t, helloDigest+"  a.warc.gz\n", map[string]string{"a.warc.gz": "goodbye\n"})
+	if err := VerifyDirectory(directory); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestVerifyDirectoryMissingFile(t *testing.T) {
+	directory := createDirectory(t, helloDigest+"  a.warc.gz\n", map[string]string{})
+	if err := VerifyDirectory(directory); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestVerifyDirectoryMissingDigest(t *testing.T) {
+	directory := createDirectory(t, helloDigest+"  a.warc.gz\n", map[string]string{"a.warc.gz": "hello\n", "b.txt": "hello\n"})
+	if err := VerifyDirectory(directory); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestVerifyDirectoryExtra(t *testing.T) {
+	directory := createDirectory(t, helloDigest+"  a.warc.gz\n", map[string]string{"a.warc.gz": "hello\n", ".complete": ""})
+	if err := VerifyDirectory(directory, ".complete"); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
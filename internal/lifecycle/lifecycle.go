@@ -0,0 +1,222 @@
+// Package lifecycle tracks what happened to every submission by correlating
+// the transfer, confirm and reject topics on identifier.
+package lifecycle
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+)
+
+// State of a submission.
+type State string
+
+const (
+	// Submitted is the state of a submission awaiting a response from DPS.
+	Submitted State = "submitted"
+	// Confirmed is the state of a submission preserved by DPS.
+	Confirmed State = "confirmed"
+	// Rejected is the state of a submission rejected by DPS.
+	Rejected State = "rejected"
+	// Resubmitted is the state of a submission replaced by a newer submission of the same package.
+	Resubmitted State = "resubmitted"
+	// TimedOut is the state of a submission without a response within the timeout.
+	TimedOut State = "timed-out"
+)
+
+// transitions lists the states that can follow each state. A response can be
+// read before its submission, as the topics are consumed independently, so a
+// submission can start out confirmed or rejected.
+var transitions = map[State][]State{
+	"":          {Submitted, Confirmed, Rejected},
+	Submitted:   {Confirmed, Rejected, Resubmitted, TimedOut},
+	TimedOut:    {Confirmed, Rejected, Resubmitted},
+	Rejected:    {Resubmitted},
+	Confirmed:   nil,
+	Resubmitted: nil,
+}
+
+// Kind of topic an event was read from.
+type Kind string
+
+const (
+	KindTransfer Kind = "transfer"
+	KindConfirm  Kind = "confirm"
+	KindReject   Kind = "reject"
+)
+
+// Event is a message read from one of the topics.
+type Event struct {
+	Kind      Kind
+	Topic     string
+	Partition int
+	Offset    int64
+	// Time is when the message was written to the topic.
+	Time    time.Time
+	Message dps.Message
+}
+
+// NewEvent returns the event of a message read from a record on a topic of the given kind.
+func NewEvent(kind Kind, record dps.Record, msg dps.Message) Event {
+	t := record.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return Event{
+		Kind:      kind,
+		Topic:     record.Topic,
+		Partition: record.Partition,
+		Offset:    record.Offset,
+		Time:      t.UTC(),
+		Message:   msg,
+	}
+}
+
+// Transition is a change of state of a submission.
+type Transition struct {
+	State State     `json:"state"`
+	Time  time.Time `json:"time"`
+	// Kind, Topic, Partition and Offset locate the event causing the transition.
+	// Kind is empty for timeouts.
+	Kind      Kind   `json:"kind,omitempty"`
+	Topic     string `json:"topic,omitempty"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+	// Identifier is the identifier of the resubmission, for transitions to Resubmitted.
+	Identifier string `json:"identifier,omitempty"`
+}
+
+// Submission is the tracked state of a single submission.
+type Submission struct {
+	Identifier      string `json:"identifier"`
+	Urn             string `json:"urn,omitempty"`
+	Path            string `json:"path,omitempty"`
+	ContentType     string `json:"contentType,omitempty"`
+	ContentCategory string `json:"contentCategory,omitempty"`
+	// Date is the date of the submission message, in dps.DateFormat.
+	Date               string `json:"date,omitempty"`
+	PreviousIdentifier string `json:"previousIdentifier,omitempty"`
+	// ResubmittedAs is the identifier of the submission replacing this one.
+	ResubmittedAs string `json:"resubmittedAs,omitempty"`
+	State         State  `json:"state"`
+	// Checks are the checks of the latest response from DPS.
+	Checks  []dps.Check  `json:"checks,omitempty"`
+	History []Transition `json:"history"`
+}
+
+// SubmittedAt returns the parsed Date of the submission, or the zero time if it is unknown.
+func (s *Submission) SubmittedAt() time.Time {
+	t, _ := time.Parse(dps.DateFormat, s.Date)
+	return t
+}
+
+// Pending reports whether the submission still awaits a response from DPS.
+func (s *Submission) Pending() bool {
+	return s.State == Submitted || s.State == TimedOut
+}
+
+func (s *Submission) transition(to State, t Transition) bool {
+	if !slices.Contains(transitions[s.State], to) {
+		return false
+	}
+	t.State = to
+	s.State = to
+	s.History = append(s.History, t)
+	return true
+}
+
+// apply updates the submission with an event about it, and reports whether its state changed.
+func (s *Submission) apply(e Event) bool {
+	msg := e.Message
+	if s.Identifier == "" {
+		s.Identifier = msg.Identifier
+	}
+	// Messages on all topics describe the submission, but the transfer message is authoritative
+	if s.Date == "" || e.Kind == KindTransfer {
+		s.Urn = msg.Urn
+		s.Path = msg.Path
+		s.ContentType = msg.ContentType
+		s.ContentCategory = msg.ContentCategory
+		s.Date = msg.Date
+		s.PreviousIdentifier = msg.PreviousIdentifier
+	}
+
+	t := Transition{Time: e.Time, Kind: e.Kind, Topic: e.Topic, Partition: e.Partition, Offset: e.Offset}
+	switch e.Kind {
+	case KindTransfer:
+		return s.transition(Submitted, t)
+	case KindConfirm:
+		s.Checks = msg.Checks
+		return s.transition(Confirmed, t)
+	case KindReject:
+		s.Checks = msg.Checks
+		return s.transition(Rejected, t)
+	default:
+		return false
+	}
+}
+
+// resubmitted marks the submission as replaced by the resubmission in e.
+func (s *Submission) resubmitted(e Event) bool {
+	t := Transition{Time: e.Time, Kind: e.Kind, Topic: e.Topic, Partition: e.Partition, Offset: e.Offset, Identifier: e.Message.Identifier}
+	if !s.transition(Resubmitted, t) {
+		return false
+	}
+	s.ResubmittedAs = e.Message.Identifier
+	return true
+}
+
+// timedOut marks the submission as timed out if it has awaited a response longer than timeout.
+func (s *Submission) timedOut(now time.Time, timeout time.Duration) bool {
+	if s.State != Submitted {
+		return false
+	}
+	submittedAt := s.SubmittedAt()
+	if submittedAt.IsZero() || now.Sub(submittedAt) <= timeout {
+		return false
+	}
+	return s.transition(TimedOut, Transition{Time: now})
+}
+
+// Correlate applies events in order to the submissions they are about, without
+// a store, and returns the submissions in order of date. A resubmission may
+// come before the submission it replaces, as when they are on different
+// partitions.
+func Correlate(events []Event) []Submission {
+	submissions := make(map[string]*Submission)
+	// Resubmissions of identifiers not seen yet
+	pending := make(map[string]Event)
+	var order []*Submission
+	for _, e := range events {
+		submission, ok := submissions[e.Message.Identifier]
+		if !ok {
+			submission = &Submission{}
+			submissions[e.Message.Identifier] = submission
+			order = append(order, submission)
+		}
+		submission.apply(e)
+		if resubmission, found := pending[e.Message.Identifier]; found {
+			delete(pending, e.Message.Identifier)
+			submission.resubmitted(resubmission)
+		}
+
+		if e.Kind == KindTransfer && e.Message.PreviousIdentifier != "" {
+			if previous, ok := submissions[e.Message.PreviousIdentifier]; ok {
+				previous.resubmitted(e)
+			} else {
+				pending[e.Message.PreviousIdentifier] = e
+			}
+		}
+	}
+
+	result := make([]Submission, len(order))
+	for i, submission := range order {
+		result[i] = *submission
+	}
+	slices.SortStableFunc(result, func(a, b Submission) int {
+		return strings.Compare(a.Date, b.Date)
+	})
+	return result
+}
@@ -0,0 +1,249 @@
+package lifecycle
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	submissionsBucket = []byte("submissions")
+	// offsetsBucket holds the next offset to apply per topic and partition,
+	// so events read more than once are applied once
+	offsetsBucket = []byte("offsets")
+	// submittedBucket indexes the identifiers of submissions awaiting a
+	// response, so timeouts are found without reading every submission
+	submittedBucket = []byte("submitted")
+)
+
+// Store is a persistent set of submissions keyed by identifier.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the lifecycle store at the given path, creating it if it does not exist.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lifecycle file '%s': %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{submissionsBucket, offsetsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		if tx.Bucket(submittedBucket) != nil {
+			return nil
+		}
+		// Files written before the index existed are indexed once
+		index, err := tx.CreateBucket(submittedBucket)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(submissionsBucket).ForEach(func(k, v []byte) error {
+			var submission Submission
+			if err := json.Unmarshal(v, &submission); err != nil {
+				return fmt.Errorf("failed to unmarshal submission '%s': %w", k, err)
+			}
+			if submission.State != Submitted {
+				return nil
+			}
+			return index.Put(k, nil)
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize lifecycle file '%s': %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func get(tx *bolt.Tx, identifier string) (*Submission, error) {
+	value := tx.Bucket(submissionsBucket).Get([]byte(identifier))
+	if value == nil {
+		return nil, nil
+	}
+	submission := new(Submission)
+	if err := json.Unmarshal(value, submission); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal submission '%s': %w", identifier, err)
+	}
+	return submission, nil
+}
+
+func put(tx *bolt.Tx, submission *Submission) error {
+	value, err := json.Marshal(submission)
+	if err != nil {
+		return fmt.Errorf("failed to marshal submission: %w", err)
+	}
+	key := []byte(submission.Identifier)
+	if err := tx.Bucket(submissionsBucket).Put(key, value); err != nil {
+		return err
+	}
+	if submission.State == Submitted {
+		return tx.Bucket(submittedBucket).Put(key, []byte{})
+	}
+	return tx.Bucket(submittedBucket).Delete(key)
+}
+
+func offsetKey(topic string, partition int) []byte {
+	return []byte(topic + "/" + strconv.Itoa(partition))
+}
+
+// Get returns the submission with the given identifier, or nil if it is unknown.
+func (s *Store) Get(identifier string) (*Submission, error) {
+	var submission *Submission
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		submission, err = get(tx, identifier)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get '%s' from lifecycle: %w", identifier, err)
+	}
+	return submission, nil
+}
+
+// Each calls fn for every submission, in order of identifier.
+func (s *Store) Each(fn func(Submission) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(submissionsBucket).ForEach(func(k, v []byte) error {
+			var submission Submission
+			if err := json.Unmarshal(v, &submission); err != nil {
+				return fmt.Errorf("failed to unmarshal submission '%s': %w", k, err)
+			}
+			return fn(submission)
+		})
+	})
+}
+
+// Overdue returns the submissions that have timed out without a response, in
+// order of date.
+func (s *Store) Overdue() ([]Submission, error) {
+	var overdue []Submission
+	err := s.Each(func(submission Submission) error {
+		if submission.State == TimedOut {
+			overdue = append(overdue, submission)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(overdue, func(a, b Submission) int {
+		return strings.Compare(a.Date, b.Date)
+	})
+	return overdue, nil
+}
+
+// Apply updates the submission the event is about, and the submission it
+// replaces if it is a resubmission. Events at offsets that have already been
+// applied are ignored. It returns the updated submission, or nil if the event
+// was ignored.
+func (s *Store) Apply(e Event) (*Submission, error) {
+	if e.Message.Identifier == "" {
+		return nil, errors.New("event has no identifier")
+	}
+
+	var updated *Submission
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		offsets := tx.Bucket(offsetsBucket)
+		key := offsetKey(e.Topic, e.Partition)
+		if next := offsets.Get(key); next != nil && e.Offset < int64(binary.BigEndian.Uint64(next)) {
+			return nil
+		}
+		next := make([]byte, 8)
+		binary.BigEndian.PutUint64(next, uint64(e.Offset+1))
+		if err := offsets.Put(key, next); err != nil {
+			return err
+		}
+
+		submission, err := get(tx, e.Message.Identifier)
+		if err != nil {
+			return err
+		}
+		if submission == nil {
+			submission = &Submission{}
+		}
+		submission.apply(e)
+		if err := put(tx, submission); err != nil {
+			return err
+		}
+		updated = submission
+
+		if e.Kind != KindTransfer || e.Message.PreviousIdentifier == "" {
+			return nil
+		}
+		previous, err := get(tx, e.Message.PreviousIdentifier)
+		if err != nil || previous == nil {
+			return err
+		}
+		if previous.resubmitted(e) {
+			return put(tx, previous)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s event at offset %d: %w", e.Kind, e.Offset, err)
+	}
+	return updated, nil
+}
+
+// Expire marks submissions that have awaited a response longer than timeout
+// as timed out, and returns them. Only submissions awaiting a response are
+// read, and the store is only written to when some of them have timed out.
+func (s *Store) Expire(now time.Time, timeout time.Duration) ([]Submission, error) {
+	var candidates []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(submittedBucket).ForEach(func(k, _ []byte) error {
+			submission, err := get(tx, string(k))
+			if err != nil {
+				return err
+			}
+			if submission != nil && submission.timedOut(now, timeout) {
+				candidates = append(candidates, submission.Identifier)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find timed out submissions: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var expired []Submission
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		for _, identifier := range candidates {
+			// A response may have arrived since the candidates were found
+			submission, err := get(tx, identifier)
+			if err != nil {
+				return err
+			}
+			if submission == nil || !submission.timedOut(now, timeout) {
+				continue
+			}
+			if err := put(tx, submission); err != nil {
+				return err
+			}
+			expired = append(expired, *submission)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire submissions: %w", err)
+	}
+	return expired, nil
+}
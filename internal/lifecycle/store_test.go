@@ -0,0 +1,236 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openStore(t *testing.T) *Store {
+	directory, err := os.MkdirTemp("", "lifecycle")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(directory) })
+
+	store, err := Open(filepath.Join(directory, "lifecycle.db"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func apply(t *testing.T, store *Store, kind Kind, offset int64, msg dps.Message) *Submission {
+	submission, err := store.Apply(Event{Kind: kind, Topic: string(kind), Offset: offset, Time: time.Now(), Message: msg})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return submission
+}
+
+func TestApply(t *testing.T) {
+	store := openStore(t)
+
+	msg := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+
+	submission := apply(t, store, KindTransfer, 0, msg)
+	if submission.State != Submitted {
+		t.Errorf("Expected %s, got %s", Submitted, submission.State)
+	}
+
+	// Events at offsets already applied are ignored
+	if submission := apply(t, store, KindTransfer, 0, msg); submission != nil {
+		t.Errorf("Expected event to be ignored, got %v", submission)
+	}
+
+	reject := msg
+	reject.Checks = []dps.Check{{Status: "FAILED", Message: "checksum mismatch"}}
+	submission = apply(t, store, KindReject, 0, reject)
+	if submission.State != Rejected || len(submission.Checks) != 1 {
+		t.Errorf("Expected %s with checks, got %v", Rejected, submission)
+	}
+
+	// A rejected submission can not be confirmed
+	submission = apply(t, store, KindConfirm, 0, msg)
+	if submission.State != Rejected {
+		t.Errorf("Expected %s, got %s", Rejected, submission.State)
+	}
+
+	resubmission, err := dps.CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	submission = apply(t, store, KindTransfer, 1, resubmission)
+	if submission.State != Submitted || submission.PreviousIdentifier != msg.Identifier {
+		t.Errorf("Expected submitted resubmission, got %v", submission)
+	}
+
+	previous, err := store.Get(msg.Identifier)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if previous.State != Resubmitted || previous.ResubmittedAs != resubmission.Identifier {
+		t.Errorf("Expected %s as %s, got %v", Resubmitted, resubmission.Identifier, previous)
+	}
+	if len(previous.History) != 3 {
+		t.Errorf("Expected 3 transitions, got %v", previous.History)
+	}
+}
+
+func TestApplyResponseBeforeSubmission(t *testing.T) {
+	store := openStore(t)
+
+	msg := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+
+	submission := apply(t, store, KindConfirm, 0, msg)
+	if submission.State != Confirmed {
+		t.Errorf("Expected %s, got %s", Confirmed, submission.State)
+	}
+	submission = apply(t, store, KindTransfer, 0, msg)
+	if submission.State != Confirmed {
+		t.Errorf("Expected %s, got %s", Confirmed, submission.State)
+	}
+	if submission.Path != msg.Path {
+		t.Errorf("Expected path %s, got %s", msg.Path, submission.Path)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	store := openStore(t)
+
+	old := dps.CreateMessage("/root/old", "old", dps.ContentTypeWarc)
+	old.Date = time.Now().UTC().Add(-48 * time.Hour).Format(dps.DateFormat)
+	recent := dps.CreateMessage("/root/recent", "recent", dps.ContentTypeWarc)
+	apply(t, store, KindTransfer, 0, old)
+	apply(t, store, KindTransfer, 1, recent)
+
+	expired, err := store.Expire(time.Now(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(expired) != 1 || expired[0].Identifier != old.Identifier || expired[0].State != TimedOut {
+		t.Errorf("Expected %s to time out, got %v", old.Identifier, expired)
+	}
+
+	overdue, err := store.Overdue()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(overdue) != 1 || overdue[0].Identifier != old.Identifier {
+		t.Errorf("Expected %s to be overdue, got %v", old.Identifier, overdue)
+	}
+
+	// A late confirmation still counts
+	submission := apply(t, store, KindConfirm, 0, old)
+	if submission.State != Confirmed {
+		t.Errorf("Expected %s, got %s", Confirmed, submission.State)
+	}
+	overdue, err = store.Overdue()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(overdue) != 0 {
+		t.Errorf("Expected no overdue submissions, got %v", overdue)
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	msg := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	resubmission, err := dps.CreateResubmission(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	resubmission.Date = time.Now().UTC().Add(time.Hour).Format(dps.DateFormat)
+
+	events := []Event{
+		{Kind: KindTransfer, Topic: "transfer", Offset: 0, Message: msg},
+		{Kind: KindTransfer, Topic: "transfer", Offset: 1, Message: resubmission},
+		{Kind: KindConfirm, Topic: "confirm", Offset: 0, Message: resubmission},
+		{Kind: KindReject, Topic: "reject", Offset: 0, Message: msg},
+	}
+	submissions := Correlate(events)
+	if len(submissions) != 2 {
+		t.Fatalf("Expected 2 submissions, got %d", len(submissions))
+	}
+	if submissions[0].Identifier != msg.Identifier || submissions[0].State != Resubmitted {
+		t.Errorf("Expected %s to be %s, got %v", msg.Identifier, Resubmitted, submissions[0])
+	}
+	if submissions[1].Identifier != resubmission.Identifier || submissions[1].State != Confirmed {
+		t.Errorf("Expected %s to be %s, got %v", resubmission.Identifier, Confirmed, submissions[1])
+	}
+
+	// The resubmission may be read first, as from another partition
+	submissions = Correlate([]Event{events[1], events[0]})
+	if submissions[0].State != Resubmitted || submissions[0].ResubmittedAs != resubmission.Identifier {
+		t.Errorf("Expected %s to be %s as %s, got %v", msg.Identifier, Resubmitted, resubmission.Identifier, submissions[0])
+	}
+}
+
+func TestSubmittedIndex(t *testing.T) {
+	directory, err := os.MkdirTemp("", "lifecycle")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "lifecycle.db")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	yesterday := time.Now().UTC().Add(-48 * time.Hour).Format(dps.DateFormat)
+	confirmed := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	confirmed.Date = yesterday
+	awaiting := dps.CreateMessage("/root/b", "b", dps.ContentTypeWarc)
+	awaiting.Date = yesterday
+	apply(t, store, KindTransfer, 0, confirmed)
+	apply(t, store, KindTransfer, 1, awaiting)
+	apply(t, store, KindConfirm, 0, confirmed)
+
+	indexed := func(store *Store) []string {
+		var identifiers []string
+		_ = store.db.View(func(tx *bolt.Tx) error {
+			return tx.Bucket(submittedBucket).ForEach(func(k, _ []byte) error {
+				identifiers = append(identifiers, string(k))
+				return nil
+			})
+		})
+		return identifiers
+	}
+	if got := indexed(store); len(got) != 1 || got[0] != awaiting.Identifier {
+		t.Errorf("Expected only %s to be indexed, got %v", awaiting.Identifier, got)
+	}
+
+	// Files written before the index existed are indexed when opened
+	err = store.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(submittedBucket)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	_ = store.Close()
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+	if got := indexed(store); len(got) != 1 || got[0] != awaiting.Identifier {
+		t.Errorf("Expected only %s to be indexed, got %v", awaiting.Identifier, got)
+	}
+
+	expired, err := store.Expire(time.Now(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(expired) != 1 || expired[0].Identifier != awaiting.Identifier {
+		t.Errorf("Expected %s to time out, got %v", awaiting.Identifier, expired)
+	}
+	if got := indexed(store); len(got) != 0 {
+		t.Errorf("Expected no indexed submissions, got %v", got)
+	}
+}
@@ -0,0 +1,226 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+)
+
+// Tracker applies the messages on the transfer, confirm and reject topics to a store.
+type Tracker struct {
+	Store     *Store
+	Transport dps.Transport
+	// Topics maps each kind of topic to its name. Kinds without a topic are not tracked.
+	Topics map[Kind]string
+	// GroupID is the consumer group used by Run.
+	GroupID string
+	// Filter selects the messages that are tracked.
+	Filter func(*dps.Message) bool
+	// DeadLetters handles messages that can not be processed, if not nil.
+	DeadLetters *dps.DeadLetters
+	// Timeout is how long a submission may await a response before it is
+	// timed out. Zero disables timeouts.
+	Timeout time.Duration
+	// OnChange, if not nil, is called with every submission whose state changed.
+	// It is never called concurrently.
+	OnChange func(Submission)
+	// OnExpire, if not nil, is called with the submissions timed out by each
+	// call to Expire, after OnChange has been called for each of them. It is
+	// called without holding up the tracking of events, so it may be slow.
+	OnExpire func([]Submission)
+
+	// mu serializes updates, so OnChange sees the state before and after each event
+	mu sync.Mutex
+}
+
+// kinds are the kinds of topics in the order they are replayed.
+var kinds = []Kind{KindTransfer, KindConfirm, KindReject}
+
+func (t *Tracker) apply(e Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var before State
+	if t.OnChange != nil {
+		previous, err := t.Store.Get(e.Message.Identifier)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			before = previous.State
+		}
+	}
+	submission, err := t.Store.Apply(e)
+	if err != nil {
+		return err
+	}
+	if submission != nil && t.OnChange != nil && submission.State != before {
+		t.OnChange(*submission)
+	}
+	return nil
+}
+
+// Expire times out submissions that have awaited a response longer than the timeout.
+func (t *Tracker) Expire(now time.Time) error {
+	if t.Timeout <= 0 {
+		return nil
+	}
+	expired, err := t.expire(now)
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 && t.OnExpire != nil {
+		t.OnExpire(expired)
+	}
+	return nil
+}
+
+func (t *Tracker) expire(now time.Time) ([]Submission, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expired, err := t.Store.Expire(now, t.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	for _, submission := range expired {
+		slog.Warn("Submission timed out", "identifier", submission.Identifier, "path", submission.Path, "date", submission.Date)
+		if t.OnChange != nil {
+			t.OnChange(submission)
+		}
+	}
+	return expired, nil
+}
+
+// ReplayTopics calls fn with an event for every message accepted by filter on
+// the topics, up to the last message at the time of calling. The transfer
+// topic is replayed first, so submissions are usually seen before their
+// responses. Messages that are not valid JSON are logged and skipped.
+func ReplayTopics(ctx context.Context, transport dps.Transport, topics map[Kind]string, filter func(*dps.Message) bool, fn func(Event) error) error {
+	for _, kind := range kinds {
+		topic, ok := topics[kind]
+		if !ok || topic == "" {
+			continue
+		}
+		err := transport.Replay(ctx, topic, func(record dps.Record) error {
+			var msg dps.Message
+			if err := json.Unmarshal(record.Value, &msg); err != nil {
+				slog.Warn("Skipped message that is not valid JSON", "topic", topic, "offset", record.Offset, "error", err)
+				return nil
+			}
+			if !filter(&msg) {
+				return nil
+			}
+			return fn(NewEvent(kind, record, msg))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to replay %s topic '%s': %w", kind, topic, err)
+		}
+	}
+	return nil
+}
+
+// Replay applies every message on the topics up to the last message at the
+// time of calling, and then times out submissions.
+func (t *Tracker) Replay(ctx context.Context) error {
+	if err := ReplayTopics(ctx, t.Transport, t.Topics, t.Filter, t.apply); err != nil {
+		return err
+	}
+	return t.Expire(time.Now())
+}
+
+// Run consumes the topics until ctx is cancelled or consuming fails, timing out
+// submissions every checkInterval. It first replays the topics up to their
+// current end, so submissions are not timed out before the responses already
+// on the topics have been applied.
+func (t *Tracker) Run(ctx context.Context, checkInterval time.Duration) error {
+	if err := t.Replay(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	stop := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for _, kind := range kinds {
+		topic, ok := t.Topics[kind]
+		if !ok || topic == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := t.consume(ctx, kind, topic); err != nil {
+				stop(err)
+			}
+		}()
+	}
+
+	if t.Timeout > 0 && checkInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(checkInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-ticker.C:
+					if err := t.Expire(now); err != nil {
+						stop(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	if firstErr == nil || errors.Is(firstErr, context.Canceled) {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+func (t *Tracker) consume(ctx context.Context, kind Kind, topic string) error {
+	consumer, err := t.Transport.Consumer(topic, t.GroupID)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	schema := dps.SchemaResponse
+	if kind == KindTransfer {
+		schema = dps.SchemaTransfer
+	}
+
+	for {
+		message, err := dps.NextMessageOf(ctx, consumer, schema, t.Filter, t.DeadLetters)
+		if err != nil {
+			return fmt.Errorf("failed to read next message from %s topic '%s': %w", kind, topic, err)
+		}
+		err = t.apply(NewEvent(kind, message.Record, message.Value))
+		if err != nil {
+			return err
+		}
+		if err := consumer.CommitRecords(ctx, message.Record); err != nil {
+			return fmt.Errorf("failed to commit message at offset %d: %w", message.Offset, err)
+		}
+	}
+}
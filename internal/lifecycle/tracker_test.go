@@ -0,0 +1,171 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+)
+
+func TestTracker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := dps.NewMemoryTransport()
+	send := func(topic string, msg dps.Message) {
+		producer, _ := transport.Producer(topic, dps.KeyRandom)
+		if err := dps.Send(ctx, producer, msg, dps.KeyRandom); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	confirmed := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	rejected := dps.CreateMessage("/root/b", "b", dps.ContentTypeWarc)
+	other := dps.CreateMessage("/root/c", "c", dps.ContentTypeWarc)
+	other.ContentCategory = "other"
+	send("transfer", confirmed)
+	send("transfer", rejected)
+	send("transfer", other)
+	send("confirm", confirmed)
+
+	var changes []Submission
+	tracker := &Tracker{
+		Store:     openStore(t),
+		Transport: transport,
+		Topics:    map[Kind]string{KindTransfer: "transfer", KindConfirm: "confirm", KindReject: "reject"},
+		GroupID:   "tracker",
+		Filter:    dps.IsWebArchiveOwned,
+		OnChange:  func(s Submission) { changes = append(changes, s) },
+	}
+	if err := tracker.Replay(ctx); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	submission, _ := tracker.Store.Get(confirmed.Identifier)
+	if submission == nil || submission.State != Confirmed {
+		t.Errorf("Expected %s, got %v", Confirmed, submission)
+	}
+	if submission, _ := tracker.Store.Get(other.Identifier); submission != nil {
+		t.Errorf("Expected %s not to be tracked, got %v", other.Identifier, submission)
+	}
+	if len(changes) != 3 {
+		t.Errorf("Expected 3 changes, got %d", len(changes))
+	}
+
+	// Run reads the topics again from the start, but applies only new messages
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- tracker.Run(runCtx, time.Hour) }()
+
+	send("reject", rejected)
+	for {
+		submission, _ := tracker.Store.Get(rejected.Identifier)
+		if submission != nil && submission.State == Rejected {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Expected %s to be rejected, got %v", rejected.Identifier, submission)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	stop()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got '%v'", err)
+	}
+	if len(changes) != 4 {
+		t.Errorf("Expected 4 changes, got %d", len(changes))
+	}
+}
+
+func TestTrackerExpire(t *testing.T) {
+	store := openStore(t)
+	old := dps.CreateMessage("/root/old", "old", dps.ContentTypeWarc)
+	old.Date = time.Now().UTC().Add(-48 * time.Hour).Format(dps.DateFormat)
+	apply(t, store, KindTransfer, 0, old)
+
+	var expired [][]Submission
+	tracker := &Tracker{
+		Store:    store,
+		Timeout:  24 * time.Hour,
+		OnExpire: func(submissions []Submission) { expired = append(expired, submissions) },
+	}
+	if err := tracker.Expire(time.Now()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	// Submissions time out only once
+	if err := tracker.Expire(time.Now()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(expired) != 1 || len(expired[0]) != 1 || expired[0][0].Identifier != old.Identifier {
+		t.Errorf("Expected %s to time out once, got %v", old.Identifier, expired)
+	}
+}
+
+func TestTrackerRunCatchesUpBeforeExpire(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := dps.NewMemoryTransport()
+	send := func(topic string, msg dps.Message) {
+		producer, _ := transport.Producer(topic, dps.KeyRandom)
+		if err := dps.Send(ctx, producer, msg, dps.KeyRandom); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	yesterday := time.Now().UTC().Add(-48 * time.Hour).Format(dps.DateFormat)
+	answered := dps.CreateMessage("/root/a", "a", dps.ContentTypeWarc)
+	answered.Date = yesterday
+	unanswered := dps.CreateMessage("/root/b", "b", dps.ContentTypeWarc)
+	unanswered.Date = yesterday
+	send("transfer", answered)
+	send("transfer", unanswered)
+	send("confirm", answered)
+
+	expired := make(chan []Submission, 10)
+	tracker := &Tracker{
+		Store:     openStore(t),
+		Transport: transport,
+		Topics:    map[Kind]string{KindTransfer: "transfer", KindConfirm: "confirm", KindReject: "reject"},
+		GroupID:   "tracker",
+		Filter:    dps.IsWebArchiveOwned,
+		Timeout:   24 * time.Hour,
+	}
+	tracker.OnExpire = func(submissions []Submission) {
+		// Events are applied while alerts are sent
+		if !tracker.mu.TryLock() {
+			t.Error("Expected OnExpire to be called without holding the lock")
+		} else {
+			tracker.mu.Unlock()
+		}
+		expired <- submissions
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- tracker.Run(runCtx, time.Millisecond) }()
+
+	select {
+	case submissions := <-expired:
+		if len(submissions) != 1 || submissions[0].Identifier != unanswered.Identifier {
+			t.Errorf("Expected only %s to time out, got %v", unanswered.Identifier, submissions)
+		}
+	case <-ctx.Done():
+		t.Fatalf("Expected %s to time out", unanswered.Identifier)
+	}
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got '%v'", err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("Expected submissions to time out once, got %v", <-expired)
+	}
+	submission, _ := tracker.Store.Get(answered.Identifier)
+	if submission == nil || submission.State != Confirmed {
+		t.Errorf("Expected %s, got %v", Confirmed, submission)
+	}
+}
@@ -0,0 +1,111 @@
+package readiness
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirPlaceholder is replaced by the base name of the directory in required file patterns.
+const DirPlaceholder = "{dir}"
+
+// Policy decides when a directory is complete and ready to be sent.
+type Policy struct {
+	// Quiescence is the period the directory and its files must have gone
+	// without modification. Zero disables the check.
+	Quiescence time.Duration
+	// MarkerFile is the name of a file that must exist in the directory. Empty
+	// disables the check.
+	MarkerFile string
+	// RequiredFiles are glob patterns that must each match at least one file
+	// in the directory.
+	RequiredFiles []string
+}
+
+// Status is the outcome of checking a directory against a policy.
+type Status struct {
+	Ready bool
+	// Reason explains why the directory is pending.
+	Reason string
+}
+
+func pending(format string, a ...any) Status {
+	return Status{Reason: fmt.Sprintf(format, a...)}
+}
+
+// Validate reports whether the policy is well-formed.
+func (p Policy) Validate() error {
+	if p.Quiescence < 0 {
+		return fmt.Errorf("quiescence period '%s' is negative", p.Quiescence)
+	}
+	for _, pattern := range p.RequiredFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid required file pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Check reports whether dir satisfies the policy at time now.
+func (p Policy) Check(dir string, now time.Time) (Status, error) {
+	name := filepath.Base(dir)
+
+	if p.MarkerFile != "" {
+		_, err := os.Stat(filepath.Join(dir, p.MarkerFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			return pending("marker file '%s' does not exist", p.MarkerFile), nil
+		}
+		if err != nil {
+			return Status{}, err
+		}
+	}
+
+	for _, pattern := range p.RequiredFiles {
+		pattern = strings.ReplaceAll(pattern, DirPlaceholder, name)
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return Status{}, fmt.Errorf("invalid required file pattern '%s': %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return pending("required file '%s' does not exist", pattern), nil
+		}
+	}
+
+	if p.Quiescence > 0 {
+		modTime, err := LastModified(dir)
+		if err != nil {
+			return Status{}, err
+		}
+		if quiet := now.Sub(modTime); quiet < p.Quiescence {
+			return pending("modified %s ago, waiting for %s without changes", quiet.Truncate(time.Second), p.Quiescence), nil
+		}
+	}
+
+	return Status{Ready: true}, nil
+}
+
+// LastModified returns the most recent modification time of dir and everything below it.
+func LastModified(dir string) (time.Time, error) {
+	var last time.Time
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read modification times of '%s': %w", dir, err)
+	}
+	return last, nil
+}
@@ -0,0 +1,91 @@
+package readiness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func createDirectory(t *testing.T, files ...string) string {
+	t.Helper()
+	root, err := os.MkdirTemp("", "readiness")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	directory := filepath.Join(root, "crawl-0001")
+	if err := os.Mkdir(directory, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(directory, file), nil, 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	return directory
+}
+
+func TestCheckRequiredFiles(t *testing.T) {
+	policy := Policy{RequiredFiles: []string{"{dir}.warc.gz", "checksum_transferred.md5"}}
+
+	directory := createDirectory(t, "crawl-0001.warc.gz")
+	status, err := policy.Check(directory, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if status.Ready {
+		t.Errorf("Expected directory without checksum file to be pending")
+	}
+
+	directory = createDirectory(t, "crawl-0001.warc.gz", "checksum_transferred.md5")
+	status, err = policy.Check(directory, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !status.Ready {
+		t.Errorf("Expected directory to be ready, got '%s'", status.Reason)
+	}
+}
+
+func TestCheckMarkerFile(t *testing.T) {
+	policy := Policy{MarkerFile: ".complete"}
+
+	status, err := policy.Check(createDirectory(t), time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if status.Ready {
+		t.Errorf("Expected directory without marker file to be pending")
+	}
+
+	status, err = policy.Check(createDirectory(t, ".complete"), time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !status.Ready {
+		t.Errorf("Expected directory to be ready, got '%s'", status.Reason)
+	}
+}
+
+func TestCheckQuiescence(t *testing.T) {
+	policy := Policy{Quiescence: 10 * time.Minute}
+	directory := createDirectory(t, "crawl-0001.warc.gz")
+
+	status, err := policy.Check(directory, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if status.Ready {
+		t.Errorf("Expected recently modified directory to be pending")
+	}
+
+	status, err = policy.Check(directory, time.Now().Add(11*time.Minute))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !status.Ready {
+		t.Errorf("Expected directory to be ready, got '%s'", status.Reason)
+	}
+}
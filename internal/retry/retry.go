@@ -0,0 +1,62 @@
+// Package retry runs operations until they succeed or a retry budget is spent.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Policy bounds the retries of an operation.
+type Policy struct {
+	// Attempts is the maximum number of attempts, including the first.
+	Attempts int
+	// Backoff is the delay after the first failed attempt. It doubles after
+	// every further failure, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+func (p Policy) Validate() error {
+	if p.Attempts < 1 {
+		return errors.New("retry attempts must be at least 1")
+	}
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("retry backoff must not be negative")
+	}
+	return nil
+}
+
+// Do calls fn until it succeeds, the attempts of the policy are spent or ctx is
+// done. onRetry, if not nil, is called before waiting for the next attempt.
+// The error of the last attempt is returned.
+func Do(ctx context.Context, p Policy, fn func(context.Context) error, onRetry func(attempt int, err error, delay time.Duration)) error {
+	attempts := max(p.Attempts, 1)
+	delay := p.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), err)
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+			delay = p.MaxBackoff
+		}
+	}
+}
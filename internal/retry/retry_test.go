@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	policy := Policy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	calls := 0
+	var delays []time.Duration
+	err := Do(context.Background(), policy, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	}, func(attempt int, err error, delay time.Duration) {
+		delays = append(delays, delay)
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+	if len(delays) != 2 || delays[0] != time.Millisecond || delays[1] != 2*time.Millisecond {
+		t.Errorf("Unexpected delays %v", delays)
+	}
+}
+
+func TestDoGivesUp(t *testing.T) {
+	policy := Policy{Attempts: 2, Backoff: time.Millisecond}
+
+	cause := errors.New("unavailable")
+	calls := 0
+	err := Do(context.Background(), policy, func(context.Context) error {
+		calls++
+		return cause
+	}, nil)
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected '%s', got '%v'", cause, err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+}
+
+func TestDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{Attempts: 10, Backoff: time.Hour}
+
+	err := Do(ctx, policy, func(context.Context) error {
+		cancel()
+		return errors.New("unavailable")
+	}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got '%v'", err)
+	}
+}
@@ -0,0 +1,276 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nlnwa/hermetic/internal/dps"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	sentBucket     = []byte("sent")
+	inFlightBucket = []byte("inFlight")
+)
+
+// Record describes a single submission to digital storage.
+type Record struct {
+	Path       string    `json:"path"`
+	Identifier string    `json:"identifier"`
+	Urn        string    `json:"urn"`
+	SentAt     time.Time `json:"sentAt"`
+	// Files is the manifest of the submission, if one was created.
+	Files []dps.File `json:"files,omitempty"`
+}
+
+// NewRecord returns the record of a submission of msg.
+func NewRecord(msg dps.Message) Record {
+	sentAt, _ := time.Parse(dps.DateFormat, msg.Date)
+	return Record{
+		Path:       msg.Path,
+		Identifier: msg.Identifier,
+		Urn:        msg.Urn,
+		SentAt:     sentAt,
+		Files:      msg.Files,
+	}
+}
+
+// Store is a persistent set of submissions keyed by path.
+type Store struct {
+	db *bolt.DB
+	// snapshot is the path of the copy opened by OpenSnapshot, removed on Close.
+	snapshot string
+}
+
+// Open opens the state store at the given path, creating it if it does not exist.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file '%s': %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{sentBucket, inFlightBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize state file '%s': %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// OpenReadOnly opens an existing state store without the ability to modify it.
+func OpenReadOnly(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file '%s': %w", path, err)
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(sentBucket) == nil {
+			return errors.New("missing bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read state file '%s': %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// snapshotTimeout is how long OpenSnapshot waits for the lock of a state file.
+var snapshotTimeout = 5 * time.Second
+
+// OpenSnapshot opens a read-only copy of an existing state store. The copy is
+// written within a read transaction, so it is consistent, and the lock of the
+// state file is released as soon as it is written, so a send started meanwhile
+// is not held up. The copy is removed on Close.
+func OpenSnapshot(path string) (*Store, error) {
+	src, err := bolt.Open(path, 0600, &bolt.Options{Timeout: snapshotTimeout, ReadOnly: true})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("failed to open state file '%s': locked for more than %s, e.g. by a running send", path, snapshotTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file '%s': %w", path, err)
+	}
+
+	dst, err := os.CreateTemp("", "hermetic-state-*.db")
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed to create snapshot of state file '%s': %w", path, err)
+	}
+	err = src.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(dst)
+		return err
+	})
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := src.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst.Name())
+		return nil, fmt.Errorf("failed to create snapshot of state file '%s': %w", path, err)
+	}
+
+	store, err := OpenReadOnly(dst.Name())
+	if err != nil {
+		_ = os.Remove(dst.Name())
+		return nil, err
+	}
+	store.snapshot = dst.Name()
+	return store, nil
+}
+
+func (s *Store) Close() error {
+	err := s.db.Close()
+	if s.snapshot != "" {
+		if removeErr := os.Remove(s.snapshot); err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
+
+// Get returns the record for path, or nil if path has not been sent.
+func (s *Store) Get(path string) (*Record, error) {
+	var record *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(sentBucket).Get([]byte(path))
+		if value == nil {
+			return nil
+		}
+		record = new(Record)
+		return json.Unmarshal(value, record)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get '%s' from state: %w", path, err)
+	}
+	return record, nil
+}
+
+// Has reports whether path has been sent.
+func (s *Store) Has(path string) (bool, error) {
+	record, err := s.Get(path)
+	if err != nil {
+		return false, err
+	}
+	return record != nil, nil
+}
+
+// Put records a submission, replacing any previous record for the same path.
+func (s *Store) Put(record Record) error {
+	if record.Path == "" {
+		return errors.New("record has no path")
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sentBucket).Put([]byte(record.Path), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put '%s' in state: %w", record.Path, err)
+	}
+	return nil
+}
+
+// Len returns the number of records in the store.
+func (s *Store) Len() (int, error) {
+	var n int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(sentBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// inFlightRecord is a submission awaiting a response from DPS.
+type inFlightRecord struct {
+	Path   string    `json:"path"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+// PutInFlight records that the submission with the given identifier awaits a
+// response from DPS since sentAt.
+func (s *Store) PutInFlight(identifier string, path string, sentAt time.Time) error {
+	value, err := json.Marshal(inFlightRecord{Path: path, SentAt: sentAt})
+	if err != nil {
+		return fmt.Errorf("failed to marshal in flight record: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(inFlightBucket).Put([]byte(identifier), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put '%s' in flight: %w", identifier, err)
+	}
+	return nil
+}
+
+// DeleteInFlight records that the submission with the given identifier no
+// longer awaits a response, and reports whether it did.
+func (s *Store) DeleteInFlight(identifier string) (bool, error) {
+	var found bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(inFlightBucket)
+		found = bucket.Get([]byte(identifier)) != nil
+		if !found {
+			return nil
+		}
+		return bucket.Delete([]byte(identifier))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete '%s' from in flight: %w", identifier, err)
+	}
+	return found, nil
+}
+
+// ExpireInFlight removes the submissions that have awaited a response since
+// before the given time, and returns their identifiers. Submissions of unknown
+// age are removed too.
+func (s *Store) ExpireInFlight(before time.Time) ([]string, error) {
+	var expired []string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(inFlightBucket)
+		err := bucket.ForEach(func(k, v []byte) error {
+			var record inFlightRecord
+			if err := json.Unmarshal(v, &record); err != nil || record.SentAt.Before(before) {
+				expired = append(expired, string(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Buckets must not be modified while iterating
+		for _, identifier := range expired {
+			if err := bucket.Delete([]byte(identifier)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire submissions in flight: %w", err)
+	}
+	return expired, nil
+}
+
+// LenInFlight returns the number of submissions awaiting a response.
+func (s *Store) LenInFlight() (int, error) {
+	var n int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(inFlightBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,224 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPutAndGet(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	store, err := Open(filepath.Join(directory, "state.db"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+
+	record, err := store.Get("/root/dir")
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if record != nil {
+		t.Errorf("Expected no record, got '%v'", record)
+	}
+
+	sentAt := time.Date(2024, 1, 3, 9, 29, 16, 0, time.UTC)
+	err = store.Put(Record{Path: "/root/dir", Identifier: "identifier", Urn: "urn", SentAt: sentAt})
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+
+	record, err = store.Get("/root/dir")
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if record == nil {
+		t.Fatalf("Expected record, got nil")
+	}
+	if record.Identifier != "identifier" || record.Urn != "urn" || !record.SentAt.Equal(sentAt) {
+		t.Errorf("Unexpected record '%v'", record)
+	}
+
+	n, err := store.Len()
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 record, got %d", n)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	file := filepath.Join(directory, "state.db")
+	store, err := Open(file)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := store.Put(Record{Path: "/root/dir"}); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+
+	store, err = Open(file)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+
+	ok, err := store.Has("/root/dir")
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if !ok {
+		t.Errorf("Expected '/root/dir' to survive reopening the store")
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	store, err := Open(filepath.Join(directory, "state.db"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+
+	for _, identifier := range []string{"a", "b"} {
+		if err := store.PutInFlight(identifier, "/root/"+identifier, time.Now()); err != nil {
+			t.Errorf("Expected no error, got '%s'", err)
+		}
+	}
+
+	found, err := store.DeleteInFlight("a")
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if !found {
+		t.Errorf("Expected 'a' to be in flight")
+	}
+	found, err = store.DeleteInFlight("c")
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if found {
+		t.Errorf("Expected 'c' not to be in flight")
+	}
+
+	n, err := store.LenInFlight()
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 in flight, got %d", n)
+	}
+}
+
+func TestExpireInFlight(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	store, err := Open(filepath.Join(directory, "state.db"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	if err := store.PutInFlight("old", "/root/old", now.Add(-2*time.Hour)); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if err := store.PutInFlight("new", "/root/new", now); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+
+	expired, err := store.ExpireInFlight(now.Add(-time.Hour))
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if len(expired) != 1 || expired[0] != "old" {
+		t.Errorf("Expected 'old' to expire, got %v", expired)
+	}
+	n, err := store.LenInFlight()
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 in flight, got %d", n)
+	}
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	directory, err := os.MkdirTemp("", "state")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "state.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := store.Put(Record{Path: "/root/dir", Identifier: "identifier"}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// The store is locked while open, and a snapshot is not copied from a file being written
+	timeout := snapshotTimeout
+	snapshotTimeout = 50 * time.Millisecond
+	defer func() { snapshotTimeout = timeout }()
+	if _, err := OpenSnapshot(path); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("Expected locked error, got '%v'", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	snapshot, err := OpenSnapshot(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	ok, err := snapshot.Has("/root/dir")
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if !ok {
+		t.Errorf("Expected '/root/dir' in snapshot")
+	}
+	// The lock is released once the snapshot is taken
+	store, err = Open(path)
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	} else {
+		_ = store.Close()
+	}
+	copyPath := snapshot.snapshot
+	if err := snapshot.Close(); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if _, err := os.Stat(copyPath); !os.IsNotExist(err) {
+		t.Errorf("Expected snapshot '%s' to be removed, got '%v'", copyPath, err)
+	}
+}
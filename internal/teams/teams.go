@@ -11,10 +11,14 @@ import (
 	"time"
 
 	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/lifecycle"
+	"github.com/nlnwa/hermetic/internal/validate"
 )
 
 const (
 	avoidMicrosoftTeamsWebhookRateLimit = 1 * time.Second
+	// maxDigestSubmissions keeps digests well below the payload limit of teams webhooks
+	maxDigestSubmissions = 50
 )
 
 type Fact struct {
@@ -55,6 +59,10 @@ func SendMessage(ctx context.Context, payload Message, webhookUrl string) error
 
 	time.Sleep(avoidMicrosoftTeamsWebhookRateLimit)
 
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code from teams: %d", resp.StatusCode)
+	}
+
 	return nil
 }
 
@@ -81,6 +89,7 @@ func Error(err error) Message {
 }
 
 func VerificationError(message *dps.KafkaMessage, rejectTopicName string, kafkaEndpoints []string) Message {
+	summary := dps.SummarizeChecks(message.Value)
 	facts := []Fact{
 		{
 			Name:  "Kafka message offset",
@@ -122,6 +131,14 @@ func VerificationError(message *dps.KafkaMessage, rejectTopicName string, kafkaE
 			Name:  "Date of submission",
 			Value: message.Value.Date,
 		},
+		{
+			Name:  "Severity",
+			Value: summary.Severity.String(),
+		},
+		{
+			Name:  "Summary",
+			Value: summary.String(),
+		},
 	}
 	for index, check := range message.Value.Checks {
 		facts = append(facts, Fact{
@@ -140,6 +157,14 @@ func VerificationError(message *dps.KafkaMessage, rejectTopicName string, kafkaE
 			Name:  fmt.Sprintf("Check #%d file", index),
 			Value: check.File,
 		})
+		facts = append(facts, Fact{
+			Name:  fmt.Sprintf("Check #%d category", index),
+			Value: string(check.Category()),
+		})
+		facts = append(facts, Fact{
+			Name:  fmt.Sprintf("Check #%d severity", index),
+			Value: check.Severity().String(),
+		})
 	}
 
 	return Message{
@@ -157,3 +182,167 @@ func VerificationError(message *dps.KafkaMessage, rejectTopicName string, kafkaE
 		},
 	}
 }
+
+func UnexpectedEntry(path string, reason string, action string) Message {
+	return Message{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: "0076D7",
+		Summary:    "Unexpected entry",
+		Sections: []Section{
+			{
+				ActivityTitle:    "Unexpected entry",
+				ActivitySubtitle: "Found an entry that can not be sent to the Digital Preservation System (DPS)",
+				ActivityImage:    "https://www.dictionary.com/e/wp-content/uploads/2018/03/thisisfine-1-300x300.jpg",
+				Facts: []Fact{
+					{
+						Name:  "Path",
+						Value: path,
+					},
+					{
+						Name:  "Reason",
+						Value: reason,
+					},
+					{
+						Name:  "Action",
+						Value: action,
+					},
+				},
+			},
+		},
+	}
+}
+
+func ValidationError(path string, violations []validate.Violation) Message {
+	facts := []Fact{
+		{
+			Name:  "Path",
+			Value: path,
+		},
+	}
+	for index, violation := range violations {
+		facts = append(facts, Fact{
+			Name:  fmt.Sprintf("Violation #%d rule", index),
+			Value: violation.Rule,
+		})
+		facts = append(facts, Fact{
+			Name:  fmt.Sprintf("Violation #%d path", index),
+			Value: violation.Path,
+		})
+		facts = append(facts, Fact{
+			Name:  fmt.Sprintf("Violation #%d message", index),
+			Value: violation.Message,
+		})
+	}
+
+	return Message{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: "0076D7",
+		Summary:    "Validation error",
+		Sections: []Section{
+			{
+				ActivityTitle:    "Validation error",
+				ActivitySubtitle: "A directory was not sent to the Digital Preservation System (DPS)",
+				ActivityImage:    "https://www.dictionary.com/e/wp-content/uploads/2018/03/thisisfine-1-300x300.jpg",
+				Facts:            facts,
+			},
+		},
+	}
+}
+
+// overdueBy returns how long the submission has exceeded the SLA, rounded to the minute.
+func overdueBy(submission lifecycle.Submission, sla time.Duration, now time.Time) string {
+	submittedAt := submission.SubmittedAt()
+	if submittedAt.IsZero() {
+		return "unknown"
+	}
+	return now.Sub(submittedAt.Add(sla)).Round(time.Minute).String()
+}
+
+func Overdue(submission lifecycle.Submission, sla time.Duration, now time.Time) Message {
+	return Message{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: "0076D7",
+		Summary:    "Overdue submission",
+		Sections: []Section{
+			{
+				ActivityTitle:    "Overdue submission",
+				ActivitySubtitle: "A submission to the Digital Preservation System (DPS) was neither confirmed nor rejected in time",
+				ActivityImage:    "https://www.dictionary.com/e/wp-content/uploads/2018/03/thisisfine-1-300x300.jpg",
+				Facts: []Fact{
+					{
+						Name:  "Identifier",
+						Value: submission.Identifier,
+					},
+					{
+						Name:  "Urn",
+						Value: submission.Urn,
+					},
+					{
+						Name:  "Path",
+						Value: submission.Path,
+					},
+					{
+						Name:  "ContentType",
+						Value: submission.ContentType,
+					},
+					{
+						Name:  "Date of submission",
+						Value: submission.Date,
+					},
+					{
+						Name:  "SLA",
+						Value: sla.String(),
+					},
+					{
+						Name:  "Overdue by",
+						Value: overdueBy(submission, sla, now),
+					},
+				},
+			},
+		},
+	}
+}
+
+func OverdueDigest(submissions []lifecycle.Submission, sla time.Duration, now time.Time) Message {
+	facts := []Fact{
+		{
+			Name:  "SLA",
+			Value: sla.String(),
+		},
+		{
+			Name:  "Overdue submissions",
+			Value: strconv.Itoa(len(submissions)),
+		},
+	}
+	for i, submission := range submissions {
+		if i == maxDigestSubmissions {
+			facts = append(facts, Fact{
+				Name:  "…",
+				Value: fmt.Sprintf("and %d more", len(submissions)-maxDigestSubmissions),
+			})
+			break
+		}
+		facts = append(facts, Fact{
+			Name:  submission.Identifier,
+			Value: fmt.Sprintf("%s, submitted %s, overdue by %s", submission.Path, submission.Date, overdueBy(submission, sla, now)),
+		})
+	}
+
+	return Message{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: "0076D7",
+		Summary:    "Overdue submissions",
+		Sections: []Section{
+			{
+				ActivityTitle:    "Overdue submissions",
+				ActivitySubtitle: "Submissions to the Digital Preservation System (DPS) that are neither confirmed nor rejected in time",
+				ActivityImage:    "https://www.dictionary.com/e/wp-content/uploads/2018/03/thisisfine-1-300x300.jpg",
+				Facts:            facts,
+			},
+		},
+	}
+}
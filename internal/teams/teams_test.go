@@ -1,12 +1,19 @@
 package teams
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/nlnwa/hermetic/internal/dps"
+	"github.com/nlnwa/hermetic/internal/lifecycle"
 )
 
 func TestCreateTeamsMessage(t *testing.T) {
@@ -91,6 +98,14 @@ func TestCreateTeamsMessage(t *testing.T) {
 						Name:  "Date of submission",
 						Value: "date",
 					},
+					{
+						Name:  "Severity",
+						Value: "warning",
+					},
+					{
+						Name:  "Summary",
+						Value: "warning: 0 failed, 0 warnings, 1 unknown, 0 passed (other)",
+					},
 					{
 						Name:  "Check #0 status",
 						Value: "status",
@@ -107,6 +122,14 @@ func TestCreateTeamsMessage(t *testing.T) {
 						Name:  "Check #0 file",
 						Value: "file",
 					},
+					{
+						Name:  "Check #0 category",
+						Value: "other",
+					},
+					{
+						Name:  "Check #0 severity",
+						Value: "warning",
+					},
 				},
 			},
 		},
@@ -117,6 +140,87 @@ func TestCreateTeamsMessage(t *testing.T) {
 
 }
 
+func TestOverdueDigest(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	submissions := []lifecycle.Submission{
+		{
+			Identifier: "first",
+			Path:       "/root/first",
+			Date:       "2024-03-01T10:30:00.000",
+		},
+		{
+			Identifier: "second",
+			Path:       "/root/second",
+		},
+	}
+
+	message := OverdueDigest(submissions, 48*time.Hour, now)
+	expectedFacts := []Fact{
+		{
+			Name:  "SLA",
+			Value: "48h0m0s",
+		},
+		{
+			Name:  "Overdue submissions",
+			Value: "2",
+		},
+		{
+			Name:  "first",
+			Value: "/root/first, submitted 2024-03-01T10:30:00.000, overdue by 25h30m0s",
+		},
+		{
+			Name:  "second",
+			Value: "/root/second, submitted , overdue by unknown",
+		},
+	}
+	if message.Summary != "Overdue submissions" || len(message.Sections) != 1 {
+		t.Fatalf("Unexpected message %s", prettify(message))
+	}
+	if !cmp.Equal(message.Sections[0].Facts, expectedFacts) {
+		t.Errorf("Expected facts to be: '%v'\n, got: \n'%v'", expectedFacts, message.Sections[0].Facts)
+	}
+
+	overdue := Overdue(submissions[0], 48*time.Hour, now)
+	if overdue.Sections[0].Facts[len(overdue.Sections[0].Facts)-1].Value != "25h30m0s" {
+		t.Errorf("Expected overdue by 25h30m0s, got %s", prettify(overdue))
+	}
+}
+
+func TestOverdueDigestLimit(t *testing.T) {
+	submissions := make([]lifecycle.Submission, maxDigestSubmissions+10)
+	for i := range submissions {
+		submissions[i] = lifecycle.Submission{Identifier: fmt.Sprintf("identifier-%d", i)}
+	}
+
+	facts := OverdueDigest(submissions, time.Hour, time.Now()).Sections[0].Facts
+	// SLA, count, the listed submissions and the remainder
+	if len(facts) != 2+maxDigestSubmissions+1 {
+		t.Fatalf("Expected %d facts, got %d", 2+maxDigestSubmissions+1, len(facts))
+	}
+	if facts[1].Value != strconv.Itoa(len(submissions)) {
+		t.Errorf("Expected count %d, got %s", len(submissions), facts[1].Value)
+	}
+	if last := facts[len(facts)-1]; last.Value != "and 10 more" {
+		t.Errorf("Expected 'and 10 more', got '%s'", last.Value)
+	}
+}
+
+func TestSendMessageStatus(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	if err := SendMessage(context.Background(), Error(errors.New("error")), server.URL); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	status = http.StatusTooManyRequests
+	if err := SendMessage(context.Background(), Error(errors.New("error")), server.URL); err == nil {
+		t.Errorf("Expected error for status %d, got nil", status)
+	}
+}
+
 func prettify(message Message) string {
 	s, err := json.MarshalIndent(message, "", "\t")
 
@@ -0,0 +1,122 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nlnwa/hermetic/internal/fixity"
+)
+
+// WarcExtension is the extension of the WARC file in a payload directory.
+const WarcExtension = ".warc.gz"
+
+const (
+	RuleNotDirectory      = "not-directory"
+	RuleMissingWarc       = "missing-warc"
+	RuleMissingChecksum   = "missing-checksum"
+	RuleUnexpectedFile    = "unexpected-file"
+	RuleUnexpectedSubdir  = "unexpected-subdirectory"
+	RuleEmptyFile         = "empty-file"
+	RuleUnsupportedFormat = "unsupported-file-type"
+)
+
+// Violation is a single way in which a directory deviates from the expected layout.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Rule, v.Path, v.Message)
+}
+
+// Error returns an error describing all violations, or nil if there are none.
+func Error(dir string, violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("'%s' has %d violation(s)", dir, len(violations))
+	for _, v := range violations {
+		err = fmt.Errorf("%w; %s", err, v)
+	}
+	return err
+}
+
+// WarcDirectory validates that dir has the layout of a WARC payload directory:
+//
+//	/<dirname>
+//	├── /<dirname>.warc.gz
+//	└── /checksum_transferred.md5
+//
+// with no other entries and no empty files. Files named in extra, such as a
+// marker file, are allowed but not required, and may be empty. The returned
+// error is only non-nil if dir could not be inspected.
+func WarcDirectory(dir string, extra ...string) ([]Violation, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []Violation{{Rule: RuleNotDirectory, Path: dir, Message: "expected a directory"}}, nil
+	}
+
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	warcName := filepath.Base(dir) + WarcExtension
+	expected := map[string]string{
+		warcName:                RuleMissingWarc,
+		fixity.ChecksumFileName: RuleMissingChecksum,
+	}
+
+	allowed := make(map[string]bool, len(extra))
+	for _, name := range extra {
+		allowed[name] = true
+	}
+
+	var violations []Violation
+	found := make(map[string]bool)
+	for _, item := range items {
+		path := filepath.Join(dir, item.Name())
+		if allowed[item.Name()] && item.Type().IsRegular() {
+			continue
+		}
+		if item.IsDir() {
+			violations = append(violations, Violation{Rule: RuleUnexpectedSubdir, Path: path, Message: "payload directory must not contain directories"})
+			continue
+		}
+		if !item.Type().IsRegular() {
+			violations = append(violations, Violation{Rule: RuleUnsupportedFormat, Path: path, Message: fmt.Sprintf("file type '%s' is not a regular file", item.Type())})
+			continue
+		}
+		if _, ok := expected[item.Name()]; !ok {
+			message := "only the WARC file and checksum file are allowed"
+			if filepath.Ext(item.Name()) == filepath.Ext(WarcExtension) {
+				message = fmt.Sprintf("WARC file must be named '%s'", warcName)
+			}
+			violations = append(violations, Violation{Rule: RuleUnexpectedFile, Path: path, Message: message})
+			continue
+		}
+		found[item.Name()] = true
+
+		info, err := item.Info()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() == 0 {
+			violations = append(violations, Violation{Rule: RuleEmptyFile, Path: path, Message: "file is empty"})
+		}
+	}
+
+	for _, name := range []string{warcName, fixity.ChecksumFileName} {
+		if !found[name] {
+			violations = append(violations, Violation{Rule: expected[name], Path: filepath.Join(dir, name), Message: "file does not exist"})
+		}
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,98 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createWarcDirectory(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root, err := os.MkdirTemp("", "validate")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	directory := filepath.Join(root, "crawl-0001")
+	if err := os.Mkdir(directory, 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	return directory
+}
+
+func rules(violations []Violation) map[string]bool {
+	r := make(map[string]bool)
+	for _, v := range violations {
+		r[v.Rule] = true
+	}
+	return r
+}
+
+func TestWarcDirectoryValid(t *testing.T) {
+	directory := createWarcDirectory(t, map[string]string{
+		"crawl-0001.warc.gz":       "warc",
+		"checksum_transferred.md5": "md5",
+	})
+	violations, err := WarcDirectory(directory)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got '%v'", violations)
+	}
+	if err := Error(directory, violations); err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestWarcDirectoryInvalid(t *testing.T) {
+	directory := createWarcDirectory(t, map[string]string{
+		"other.warc.gz":            "warc",
+		"checksum_transferred.md5": "",
+	})
+	violations, err := WarcDirectory(directory)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	r := rules(violations)
+	for _, rule := range []string{RuleUnexpectedFile, RuleEmptyFile, RuleMissingWarc} {
+		if !r[rule] {
+			t.Errorf("Expected violation '%s', got '%v'", rule, violations)
+		}
+	}
+	if r[RuleMissingChecksum] {
+		t.Errorf("Expected no violation '%s', got '%v'", RuleMissingChecksum, violations)
+	}
+	if err := Error(directory, violations); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestWarcDirectoryExtra(t *testing.T) {
+	directory := createWarcDirectory(t, map[string]string{
+		"crawl-0001.warc.gz":       "warc",
+		"checksum_transferred.md5": "md5",
+		".complete":                "",
+	})
+	violations, err := WarcDirectory(directory, ".complete")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got '%v'", violations)
+	}
+
+	violations, err = WarcDirectory(directory)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !rules(violations)[RuleUnexpectedFile] {
+		t.Errorf("Expected violation '%s', got '%v'", RuleUnexpectedFile, violations)
+	}
+}
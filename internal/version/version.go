@@ -0,0 +1,18 @@
+package version
+
+import "runtime/debug"
+
+// Version is set at build time with -ldflags "-X github.com/nlnwa/hermetic/internal/version.Version=<version>".
+var Version = ""
+
+// Get returns the version of hermetic, falling back to the module version
+// recorded by the go tool, or "dev".
+func Get() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
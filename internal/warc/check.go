@@ -0,0 +1,166 @@
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Report is the outcome of checking the integrity of a gzip compressed WARC file.
+type Report struct {
+	Path    string `json:"path"`
+	Members int    `json:"members"`
+	Records int    `json:"records"`
+	// Error describes the first problem found, if any. Checking stops at
+	// the first problem since the rest of the file can not be trusted.
+	Error string `json:"error,omitempty"`
+}
+
+// CheckDirectory checks every gzip compressed WARC file in dir.
+func CheckDirectory(dir string) ([]Report, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.warc.gz"))
+	if err != nil {
+		return nil, err
+	}
+	var reports []Report
+	for _, path := range paths {
+		report, err := CheckFile(path)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (r Report) Err() error {
+	if r.Error == "" {
+		return nil
+	}
+	return fmt.Errorf("integrity check of '%s' failed: %s", r.Path, r.Error)
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// CheckFile checks that every gzip member of the file at path decompresses
+// cleanly, that every record has a valid WARC/1.0 or WARC/1.1 header with
+// WARC-Type, WARC-Record-ID and Content-Length, and that the first record is
+// a warcinfo record. The returned error is only non-nil if the file could not
+// be opened.
+func CheckFile(path string) (Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Report{}, err
+	}
+	defer f.Close()
+
+	report := Check(f)
+	report.Path = path
+	return report, nil
+}
+
+// Check checks the gzip compressed WARC content of r, see CheckFile.
+func Check(r io.Reader) Report {
+	var report Report
+
+	counter := &countingReader{r: r}
+	br := bufio.NewReader(counter)
+	offset := func() int64 {
+		return counter.n - int64(br.Buffered())
+	}
+
+	zr := new(gzip.Reader)
+	for {
+		memberOffset := offset()
+		if err := zr.Reset(br); err != nil {
+			if errors.Is(err, io.EOF) {
+				if report.Members == 0 {
+					report.Error = "file is empty"
+				}
+				return report
+			}
+			report.Error = fmt.Sprintf("gzip member at offset %d: %s", memberOffset, err)
+			return report
+		}
+		zr.Multistream(false)
+		report.Members++
+
+		records, err := checkRecords(bufio.NewReader(zr), report.Records == 0)
+		report.Records += records
+		if err == nil {
+			// Drain the member to verify its checksum and size
+			_, err = io.Copy(io.Discard, zr)
+		}
+		if err != nil {
+			report.Error = fmt.Sprintf("gzip member at offset %d: %s", memberOffset, err)
+			return report
+		}
+	}
+}
+
+// checkRecords parses WARC records until the end of r and returns the number of records read.
+func checkRecords(r *bufio.Reader, first bool) (int, error) {
+	records := 0
+	tp := textproto.NewReader(r)
+	for {
+		if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+			if records == 0 {
+				return 0, errors.New("member contains no WARC record")
+			}
+			return records, nil
+		}
+
+		version, err := tp.ReadLine()
+		if err != nil {
+			return records, fmt.Errorf("record %d: failed to read version: %w", records, err)
+		}
+		if version != "WARC/1.0" && version != "WARC/1.1" {
+			return records, fmt.Errorf("record %d: unsupported version '%s'", records, version)
+		}
+
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return records, fmt.Errorf("record %d: failed to read header: %w", records, err)
+		}
+		recordType := header.Get("WARC-Type")
+		if recordType == "" {
+			return records, fmt.Errorf("record %d: missing WARC-Type", records)
+		}
+		if first && records == 0 && recordType != "warcinfo" {
+			return records, fmt.Errorf("record %d: first record is '%s', expected 'warcinfo'", records, recordType)
+		}
+		if header.Get("WARC-Record-ID") == "" {
+			return records, fmt.Errorf("record %d: missing WARC-Record-ID", records)
+		}
+		length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil || length < 0 {
+			return records, fmt.Errorf("record %d: invalid Content-Length '%s'", records, header.Get("Content-Length"))
+		}
+
+		if _, err := io.CopyN(io.Discard, r, length); err != nil {
+			return records, fmt.Errorf("record %d: failed to read %d bytes of content: %w", records, length, err)
+		}
+		trailer := make([]byte, 4)
+		if _, err := io.ReadFull(r, trailer); err != nil || string(trailer) != "\r\n\r\n" {
+			return records, fmt.Errorf("record %d: missing record trailer %s", records, strings.TrimSpace(strconv.Quote(string(trailer))))
+		}
+		records++
+	}
+}
@@ -0,0 +1,82 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"testing"
+)
+
+func record(recordType string, content string) string {
+	return fmt.Sprintf("WARC/1.1\r\nWARC-Type: %s\r\nWARC-Record-ID: <urn:uuid:%s>\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n", recordType, recordType, len(content), content)
+}
+
+func compress(t *testing.T, members ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	for _, member := range members {
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write([]byte(member)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	return b.Bytes()
+}
+
+func TestCheckValid(t *testing.T) {
+	data := compress(t, record("warcinfo", "software: test"), record("response", "HTTP/1.1 200 OK\r\n\r\nhello"))
+
+	report := Check(bytes.NewReader(data))
+	if err := report.Err(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if report.Members != 2 || report.Records != 2 {
+		t.Errorf("Expected 2 members and 2 records, got %d members and %d records", report.Members, report.Records)
+	}
+}
+
+func TestCheckFirstRecordNotWarcinfo(t *testing.T) {
+	data := compress(t, record("response", "hello"))
+
+	if err := Check(bytes.NewReader(data)).Err(); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestCheckInvalidHeader(t *testing.T) {
+	data := compress(t, record("warcinfo", ""), "WARC/1.1\r\nWARC-Type: response\r\nContent-Length: 0\r\n\r\n\r\n\r\n")
+
+	report := Check(bytes.NewReader(data))
+	if report.Err() == nil {
+		t.Errorf("Expected error for missing WARC-Record-ID, got nil")
+	}
+	if report.Records != 1 {
+		t.Errorf("Expected 1 valid record, got %d", report.Records)
+	}
+}
+
+func TestCheckTruncated(t *testing.T) {
+	data := compress(t, record("warcinfo", ""), record("response", "hello"))
+
+	if err := Check(bytes.NewReader(data[:len(data)-10])).Err(); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestCheckCorrupt(t *testing.T) {
+	data := compress(t, record("warcinfo", ""), record("response", "hello"))
+	data[len(data)-20] ^= 0xff
+
+	if err := Check(bytes.NewReader(data)).Err(); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestCheckEmpty(t *testing.T) {
+	if err := Check(bytes.NewReader(nil)).Err(); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
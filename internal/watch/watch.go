@@ -0,0 +1,69 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch calls scan once immediately, then whenever an entry is created in or
+// moved into dir, and at least every rescanInterval as a safety net for missed
+// events and file systems where inotify is unreliable, such as NFS. Watch
+// returns nil when ctx is cancelled, or the first error returned by scan.
+//
+// If watching for events is disabled or unsupported, Watch falls back to
+// periodic rescans only.
+func Watch(ctx context.Context, dir string, rescanInterval time.Duration, events bool, scan func(context.Context) error) error {
+	if rescanInterval <= 0 {
+		return fmt.Errorf("rescan interval must be positive, got '%s'", rescanInterval)
+	}
+
+	var changes <-chan fsnotify.Event
+	var errs <-chan error
+	if events {
+		watcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			err = watcher.Add(dir)
+		}
+		if err != nil {
+			slog.Warn("Failed to watch directory for changes, falling back to periodic rescan", "path", dir, "interval", rescanInterval, "error", err)
+		} else {
+			defer watcher.Close()
+			changes = watcher.Events
+			errs = watcher.Errors
+		}
+	}
+
+	ticker := time.NewTicker(rescanInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := scan(ctx); err != nil {
+			if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				break wait
+			case event := <-changes:
+				if event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+					slog.Debug("Detected change", "path", event.Name, "op", event.Op.String())
+					break wait
+				}
+			case err := <-errs:
+				slog.Warn("Error while watching directory", "path", dir, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchReactsToNewDirectory(t *testing.T) {
+	directory, err := os.MkdirTemp("", "watch")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	scans := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, directory, time.Hour, true, func(context.Context) error {
+			scans <- struct{}{}
+			return nil
+		})
+	}()
+
+	<-scans
+	if err := os.Mkdir(filepath.Join(directory, "new"), 0755); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	select {
+	case <-scans:
+	case <-ctx.Done():
+		t.Fatalf("Expected scan after creating directory")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestWatchRescansPeriodically(t *testing.T) {
+	directory, err := os.MkdirTemp("", "watch")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n := 0
+	err = Watch(ctx, directory, 10*time.Millisecond, false, func(context.Context) error {
+		n++
+		if n == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got '%s'", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 scans, got %d", n)
+	}
+}